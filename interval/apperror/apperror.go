@@ -0,0 +1,90 @@
+package apperror
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Error is an error that can be shown to API clients. Code is a stable
+// machine readable identifier, Message is safe to return to the user and
+// Cause keeps the underlying error for logs and errors.Is/As.
+type Error struct {
+	Code    string
+	Status  int
+	Message string
+	Cause   error
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return e.Message + ": " + e.Cause.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether target is an *Error with the same code, so a sentinel
+// still matches after Wrap.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap returns a copy of e that carries cause
+func (e *Error) Wrap(cause error) *Error {
+	wrapped := *e
+	wrapped.Cause = cause
+	return &wrapped
+}
+
+func New(status int, code, message string) *Error {
+	return &Error{Code: code, Status: status, Message: message}
+}
+
+func BadRequest(code, message string) *Error {
+	return New(http.StatusBadRequest, code, message)
+}
+
+func Unauthorized(code, message string) *Error {
+	return New(http.StatusUnauthorized, code, message)
+}
+
+func Forbidden(code, message string) *Error {
+	return New(http.StatusForbidden, code, message)
+}
+
+func NotFound(code, message string) *Error {
+	return New(http.StatusNotFound, code, message)
+}
+
+func Conflict(code, message string) *Error {
+	return New(http.StatusConflict, code, message)
+}
+
+// Internal hides cause behind a generic message
+func Internal(cause error) *Error {
+	return &Error{
+		Code:    CodeInternal,
+		Status:  http.StatusInternalServerError,
+		Message: "Internal server error",
+		Cause:   cause,
+	}
+}
+
+// Codes shared by several layers
+const (
+	CodeInternal         = "internal_error"
+	CodeValidationFailed = "validation_failed"
+)
+
+// From returns the *Error in err's chain, or an internal error wrapping err
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return Internal(err)
+}
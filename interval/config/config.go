@@ -0,0 +1,335 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	database "github.com/nightx1x/ecommerce/interval/db"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// Config holds every setting the application reads at startup
+type Config struct {
+	App     AppConfig
+	DB      database.Config
+	JWT     JWTConfig
+	Storage StorageConfig
+}
+
+type AppConfig struct {
+	Env  string
+	Name string
+	Port int
+}
+
+type JWTConfig struct {
+	Secret            string
+	Expiration        time.Duration
+	RefreshExpiration time.Duration
+}
+
+const (
+	StorageLocal = "local"
+	StorageS3    = "s3"
+)
+
+// StorageConfig selects where uploaded files such as product images are kept
+type StorageConfig struct {
+	Driver string
+	// MaxUploadSize caps a single upload, in bytes
+	MaxUploadSize int64
+
+	LocalDir string
+	// PublicURL is where stored files are served from: the path the app
+	// serves local files under, or a CDN or public host in front of the
+	// bucket. Empty for S3 means the bucket URL itself.
+	PublicURL string
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+}
+
+// IsProduction reports whether the application runs with APP_ENV=production
+func (c *Config) IsProduction() bool {
+	return c.App.Env == EnvProduction
+}
+
+// Addr returns the listen address for the HTTP server
+func (c *Config) Addr() string {
+	return ":" + strconv.Itoa(c.App.Port)
+}
+
+// Options controls where Load looks for configuration sources
+type Options struct {
+	// EnvFile is the dotenv file to read, ".env" by default
+	EnvFile string
+	// YAMLFile is an optional YAML file, CONFIG_FILE env by default
+	YAMLFile string
+}
+
+// FieldError describes a single invalid configuration key
+type FieldError struct {
+	Key    string
+	Reason string
+}
+
+// Error is returned by Load and lists all invalid keys at once
+type Error struct {
+	Fields []FieldError
+}
+
+func (e *Error) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Key+": "+f.Reason)
+	}
+	return "invalid configuration: " + strings.Join(parts, "; ")
+}
+
+func defaults() map[string]string {
+	return map[string]string{
+		"APP_ENV":                  EnvDevelopment,
+		"APP_NAME":                 "ecommerce-api",
+		"APP_PORT":                 "8080",
+		"DB_HOST":                  "localhost",
+		"DB_PORT":                  "5432",
+		"DB_USER":                  "",
+		"DB_PASSWORD":              "",
+		"DB_NAME":                  "ecommerce_db",
+		"DB_SSLMODE":               "disable",
+		"JWT_SECRET":               "",
+		"JWT_EXPIRATION":           "24h",
+		"REFRESH_TOKEN_EXPIRATION": "168h",
+		"STORAGE_DRIVER":           StorageLocal,
+		"STORAGE_LOCAL_DIR":        "./uploads",
+		"STORAGE_PUBLIC_URL":       "",
+		"STORAGE_MAX_UPLOAD_SIZE":  "10485760",
+		"S3_ENDPOINT":              "",
+		"S3_REGION":                "us-east-1",
+		"S3_BUCKET":                "",
+		"S3_ACCESS_KEY":            "",
+		"S3_SECRET_KEY":            "",
+	}
+}
+
+// Load builds Config from layered sources, later ones overriding earlier:
+// defaults, optional YAML file, .env file, process environment.
+func Load() (*Config, error) {
+	return LoadWithOptions(Options{})
+}
+
+// LoadWithOptions is Load with explicit source locations
+func LoadWithOptions(opts Options) (*Config, error) {
+	if opts.EnvFile == "" {
+		opts.EnvFile = ".env"
+	}
+	if opts.YAMLFile == "" {
+		opts.YAMLFile = os.Getenv("CONFIG_FILE")
+	}
+
+	values := defaults()
+
+	if opts.YAMLFile != "" {
+		fromYAML, err := readYAML(opts.YAMLFile)
+		if err != nil {
+			return nil, err
+		}
+		merge(values, fromYAML)
+	}
+
+	// .env відсутній у Docker — це нормально
+	fromDotenv, err := godotenv.Read(opts.EnvFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read %s: %w", opts.EnvFile, err)
+	}
+	merge(values, fromDotenv)
+
+	for key := range values {
+		if v, ok := os.LookupEnv(key); ok && v != "" {
+			values[key] = v
+		}
+	}
+
+	return parse(values)
+}
+
+func parse(values map[string]string) (*Config, error) {
+	p := &parser{values: values}
+
+	cfg := &Config{
+		App: AppConfig{
+			Env:  p.oneOf("APP_ENV", EnvDevelopment, EnvProduction),
+			Name: values["APP_NAME"],
+			Port: p.port("APP_PORT"),
+		},
+		DB: database.Config{
+			Host:     values["DB_HOST"],
+			User:     values["DB_USER"],
+			Password: values["DB_PASSWORD"],
+			DBName:   values["DB_NAME"],
+			SSLMode:  p.oneOf("DB_SSLMODE", "disable", "allow", "prefer", "require", "verify-ca", "verify-full"),
+		},
+		JWT: JWTConfig{
+			Secret:            values["JWT_SECRET"],
+			Expiration:        p.duration("JWT_EXPIRATION"),
+			RefreshExpiration: p.duration("REFRESH_TOKEN_EXPIRATION"),
+		},
+		Storage: StorageConfig{
+			Driver:        p.oneOf("STORAGE_DRIVER", StorageLocal, StorageS3),
+			MaxUploadSize: p.size("STORAGE_MAX_UPLOAD_SIZE"),
+			LocalDir:      values["STORAGE_LOCAL_DIR"],
+			PublicURL:     values["STORAGE_PUBLIC_URL"],
+			S3Endpoint:    values["S3_ENDPOINT"],
+			S3Region:      values["S3_REGION"],
+			S3Bucket:      values["S3_BUCKET"],
+			S3AccessKey:   values["S3_ACCESS_KEY"],
+			S3SecretKey:   values["S3_SECRET_KEY"],
+		},
+	}
+	if port := p.port("DB_PORT"); port > 0 {
+		cfg.DB.Port = strconv.Itoa(port)
+	}
+
+	p.required("DB_HOST", "DB_NAME")
+	switch cfg.Storage.Driver {
+	case StorageLocal:
+		p.required("STORAGE_LOCAL_DIR")
+		if cfg.Storage.PublicURL == "" {
+			cfg.Storage.PublicURL = "/uploads"
+		}
+	case StorageS3:
+		p.required("S3_ENDPOINT", "S3_REGION", "S3_BUCKET", "S3_ACCESS_KEY", "S3_SECRET_KEY")
+	}
+	if cfg.IsProduction() {
+		p.required("DB_USER", "DB_PASSWORD", "JWT_SECRET")
+		if s := values["JWT_SECRET"]; s != "" && len(s) < 32 {
+			p.fail("JWT_SECRET", "must be at least 32 characters in production")
+		}
+	}
+
+	// Якщо Docker відсутній, а DB_HOST вказує на "db" — підміняємо на localhost
+	if cfg.DB.Host == "db" && !isRunningInDocker() {
+		cfg.DB.Host = "localhost"
+	}
+
+	if len(p.errs) > 0 {
+		return nil, &Error{Fields: p.errs}
+	}
+	return cfg, nil
+}
+
+type parser struct {
+	values map[string]string
+	errs   []FieldError
+}
+
+func (p *parser) fail(key, reason string) {
+	p.errs = append(p.errs, FieldError{Key: key, Reason: reason})
+}
+
+func (p *parser) required(keys ...string) {
+	for _, key := range keys {
+		if p.values[key] == "" {
+			p.fail(key, "is required")
+		}
+	}
+}
+
+func (p *parser) port(key string) int {
+	port, err := strconv.Atoi(p.values[key])
+	if err != nil || port < 1 || port > 65535 {
+		p.fail(key, fmt.Sprintf("must be a port number between 1 and 65535, got %q", p.values[key]))
+		return 0
+	}
+	return port
+}
+
+func (p *parser) duration(key string) time.Duration {
+	d, err := time.ParseDuration(p.values[key])
+	if err != nil || d <= 0 {
+		p.fail(key, fmt.Sprintf("must be a positive duration like 24h, got %q", p.values[key]))
+		return 0
+	}
+	return d
+}
+
+func (p *parser) size(key string) int64 {
+	n, err := strconv.ParseInt(p.values[key], 10, 64)
+	if err != nil || n <= 0 {
+		p.fail(key, fmt.Sprintf("must be a positive number of bytes, got %q", p.values[key]))
+		return 0
+	}
+	return n
+}
+
+func (p *parser) oneOf(key string, allowed ...string) string {
+	v := p.values[key]
+	for _, a := range allowed {
+		if v == a {
+			return v
+		}
+	}
+	p.fail(key, fmt.Sprintf("must be one of %s, got %q", strings.Join(allowed, ", "), v))
+	return v
+}
+
+// readYAML reads a nested YAML file and flattens it into env-style keys,
+// so that `db: {host: x}` becomes DB_HOST=x.
+func readYAML(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	out := map[string]string{}
+	flatten("", raw, out)
+	return out, nil
+}
+
+func flatten(prefix string, in map[string]interface{}, out map[string]string) {
+	for k, v := range in {
+		key := strings.ToUpper(k)
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+		switch val := v.(type) {
+		case map[string]interface{}:
+			flatten(key, val, out)
+		case nil:
+			out[key] = ""
+		default:
+			out[key] = fmt.Sprint(val)
+		}
+	}
+}
+
+func merge(dst, src map[string]string) {
+	for k, v := range src {
+		if v != "" {
+			dst[k] = v
+		}
+	}
+}
+
+// isRunningInDocker перевіряє, чи запущено застосунок у Docker контейнері
+func isRunningInDocker() bool {
+	_, err := os.Stat("/.dockerenv")
+	return err == nil
+}
@@ -0,0 +1,70 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+var testCursor = Cursor{
+	Sort:     "price_asc",
+	Key:      "19.99",
+	ID:       uuid.MustParse("6f1c9a52-3b1e-4c1a-9d55-0d5b8e7f2a10"),
+	Backward: true,
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	c := NewCodec("secret")
+	token := c.Encode(testCursor)
+
+	got, err := c.Decode(token)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if got != testCursor {
+		t.Errorf("Decode = %+v, want %+v", got, testCursor)
+	}
+}
+
+func TestCodecRejectsTampering(t *testing.T) {
+	c := NewCodec("secret")
+	token := c.Encode(testCursor)
+	body, sig, _ := strings.Cut(token, ".")
+
+	// A payload the client edited, e.g. to jump to another position
+	payload := `{"s":"price_asc","k":"0.01","i":"` + testCursor.ID.String() + `","b":true}`
+	forgedBody := base64.RawURLEncoding.EncodeToString([]byte(payload))
+
+	// The last signature byte flipped
+	raw, _ := base64.RawURLEncoding.DecodeString(sig)
+	raw[len(raw)-1] ^= 1
+	flipped := base64.RawURLEncoding.EncodeToString(raw)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "empty", token: ""},
+		{name: "no signature", token: body},
+		{name: "edited payload", token: forgedBody + "." + sig},
+		{name: "flipped signature", token: body + "." + flipped},
+		{name: "truncated signature", token: body + "." + sig[:len(sig)-2]},
+		{name: "signature not base64", token: body + ".!!!"},
+		{name: "signed with another secret", token: NewCodec("other").Encode(testCursor)},
+		{name: "signed payload not JSON", token: signed(c, "not json")},
+	}
+	for _, tt := range tests {
+		if _, err := c.Decode(tt.token); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: Decode error = %v, want ErrInvalid", tt.name, err)
+		}
+	}
+}
+
+// signed returns a correctly signed token for an arbitrary payload
+func signed(c *Codec, payload string) string {
+	body := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return body + "." + base64.RawURLEncoding.EncodeToString(c.sign(body))
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Attribute types, each stored in its own value column
+const (
+	AttributeText    = "text"
+	AttributeNumber  = "number"
+	AttributeBoolean = "boolean"
+)
+
+// Attribute is a typed product property such as brand or capacity.
+// Filterable attributes can be filtered on and are listed as facets.
+type Attribute struct {
+	ID         uuid.UUID `db:"id" json:"id"`
+	Code       string    `db:"code" json:"code"`
+	Name       string    `db:"name" json:"name"`
+	Type       string    `db:"type" json:"type"`
+	Unit       *string   `db:"unit" json:"unit,omitempty"`
+	Filterable bool      `db:"filterable" json:"filterable"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+// AttributeValue is the value of an attribute; only the field of the
+// attribute's type is set
+type AttributeValue struct {
+	Text   *string  `db:"value_text" json:"-"`
+	Number *float64 `db:"value_number" json:"-"`
+	Bool   *bool    `db:"value_bool" json:"-"`
+}
+
+// Scalar returns the set value as a string, float64 or bool, or nil
+func (v AttributeValue) Scalar() any {
+	switch {
+	case v.Text != nil:
+		return *v.Text
+	case v.Number != nil:
+		return *v.Number
+	case v.Bool != nil:
+		return *v.Bool
+	}
+	return nil
+}
+
+// ProductAttribute is the value of one attribute of a product
+type ProductAttribute struct {
+	ProductID   uuid.UUID `db:"product_id" json:"-"`
+	AttributeID uuid.UUID `db:"attribute_id" json:"attribute_id"`
+	Code        string    `db:"code" json:"code"`
+	Name        string    `db:"name" json:"name"`
+	Type        string    `db:"type" json:"type"`
+	Unit        *string   `db:"unit" json:"unit,omitempty"`
+	AttributeValue
+}
+
+// MarshalJSON implements json.Marshaler. The value is written as a plain
+// JSON string, number or boolean.
+func (a ProductAttribute) MarshalJSON() ([]byte, error) {
+	type plain ProductAttribute
+	return json.Marshal(struct {
+		plain
+		Value any `json:"value"`
+	}{plain(a), a.Scalar()})
+}
+
+// AttributeFilter keeps products whose value of the attribute is one of
+// Values (text, compared lower-cased), Bools or Numbers, and for numbers
+// also within [Min, Max]
+type AttributeFilter struct {
+	AttributeID uuid.UUID
+	Type        string
+	Values      []string
+	Numbers     []float64
+	Bools       []bool
+	Min         *float64
+	Max         *float64
+}
+
+// Matches reports whether v passes the filter, e.g. to mark selected facet
+// values
+func (f AttributeFilter) Matches(v AttributeValue) bool {
+	switch {
+	case v.Text != nil:
+		return slices.Contains(f.Values, strings.ToLower(*v.Text))
+	case v.Bool != nil:
+		return slices.Contains(f.Bools, *v.Bool)
+	case v.Number != nil:
+		n := *v.Number
+		if len(f.Numbers) > 0 && !slices.Contains(f.Numbers, n) {
+			return false
+		}
+		return (f.Min == nil || n >= *f.Min) && (f.Max == nil || n <= *f.Max)
+	}
+	return false
+}
+
+// FacetCount is the number of matching products with a value of an
+// attribute
+type FacetCount struct {
+	AttributeID uuid.UUID `db:"attribute_id"`
+	AttributeValue
+	Count int `db:"count"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is a persisted, revocable refresh token. Only the SHA-256
+// hash of the token is stored.
+type RefreshToken struct {
+	ID         uuid.UUID  `db:"id" json:"id"`
+	UserID     uuid.UUID  `db:"user_id" json:"user_id"`
+	TokenHash  string     `db:"token_hash" json:"-"`
+	ExpiresAt  time.Time  `db:"expires_at" json:"expires_at"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+	ReplacedBy *uuid.UUID `db:"replaced_by" json:"replaced_by,omitempty"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+}
@@ -6,10 +6,13 @@ import (
 	"github.com/google/uuid"
 )
 
-type Cart struct {
-	ID        uuid.UUID         `json:"id"`
-	UserID    uuid.UUID         `json:"user_id"`
-	Items     map[uuid.UUID]int `json:"items"` // product ID to quantity
-	CreatedAt time.Time         `json:"created_at"`
-	UpdatedAt time.Time         `json:"updated_at"`
+// CartItem is a single row of cart_items: one product, or one variant of it,
+// in a user's cart
+type CartItem struct {
+	ID        uuid.UUID  `db:"id" json:"id"`
+	UserID    uuid.UUID  `db:"user_id" json:"user_id"`
+	ProductID uuid.UUID  `db:"product_id" json:"product_id"`
+	VariantID *uuid.UUID `db:"variant_id" json:"variant_id,omitempty"`
+	Quantity  int        `db:"quantity" json:"quantity"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
 }
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Category struct {
+	ID          uuid.UUID  `db:"id" json:"id"`
+	Name        string     `db:"name" json:"name"`
+	Description *string    `db:"description" json:"description"`
+	ParentID    *uuid.UUID `db:"parent_id" json:"parent_id"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+}
+
+// CategoryWithCount is a category with the number of products assigned to it
+type CategoryWithCount struct {
+	Category
+	ProductCount int `db:"product_count" json:"product_count"`
+}
+
+// CategoryNode is a category with its nested subcategories
+type CategoryNode struct {
+	CategoryWithCount
+	Children []*CategoryNode `json:"children"`
+}
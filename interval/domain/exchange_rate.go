@@ -0,0 +1,136 @@
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidRate is returned for exchange rates that are not positive decimals
+var ErrInvalidRate = errors.New("invalid exchange rate")
+
+// Rate is an exact exchange rate: the price of one unit of a currency in
+// DefaultCurrency, e.g. 45.1234 for EUR. The zero value is a rate of 1.
+type Rate struct {
+	r *big.Rat
+}
+
+// BaseRate is the rate of DefaultCurrency itself
+var BaseRate = Rate{}
+
+// ParseRate parses a positive decimal rate such as "45.1234"
+func ParseRate(s string) (Rate, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok || r.Sign() <= 0 || strings.ContainsAny(s, "eE/") {
+		return Rate{}, fmt.Errorf("%w: %q", ErrInvalidRate, s)
+	}
+	return Rate{r: r}, nil
+}
+
+// IsSet reports whether the rate was parsed or scanned rather than left at
+// the zero value, which only stands in for BaseRate
+func (r Rate) IsSet() bool {
+	return r.r != nil
+}
+
+// Rat returns a copy of the rate as a fraction
+func (r Rate) Rat() *big.Rat {
+	if r.r == nil {
+		return big.NewRat(1, 1)
+	}
+	return new(big.Rat).Set(r.r)
+}
+
+// Inverse returns 1/r, the rate in the opposite direction
+func (r Rate) Inverse() Rate {
+	return Rate{r: new(big.Rat).Inv(r.Rat())}
+}
+
+// String formats the rate with up to 8 decimals, as stored
+func (r Rate) String() string {
+	s := r.Rat().FloatString(8)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// MarshalJSON implements json.Marshaler. Rates are strings, like amounts.
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler for strings and numbers.
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	s := string(data)
+	if data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	parsed, err := ParseRate(s)
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (r Rate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+// Scan implements sql.Scanner.
+func (r *Rate) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return r.scanString(string(v))
+	case string:
+		return r.scanString(v)
+	case nil:
+		*r = Rate{}
+		return nil
+	default:
+		return errors.New("unsupported type for exchange rate")
+	}
+}
+
+func (r *Rate) scanString(s string) error {
+	parsed, err := ParseRate(s)
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// ExchangeRate is a row of exchange_rates. The latest rate whose
+// EffectiveFrom has passed applies.
+type ExchangeRate struct {
+	ID            uuid.UUID `db:"id" json:"id"`
+	Currency      string    `db:"currency" json:"currency"`
+	Rate          Rate      `db:"rate" json:"rate"`
+	EffectiveFrom time.Time `db:"effective_from" json:"effective_from"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+}
+
+// ProductPrice is an explicit price of a product in a currency other than
+// DefaultCurrency, used instead of converting products.price
+type ProductPrice struct {
+	ProductID uuid.UUID `db:"product_id" json:"product_id"`
+	Currency  string    `db:"currency" json:"currency"`
+	// Price is in Currency; the repository fills it from the NUMERIC column
+	Price     Money     `db:"-" json:"price"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProductImage is one picture in a product's gallery. Images are ordered by
+// Position; the first one is mirrored into products.image_url.
+type ProductImage struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	ProductID uuid.UUID `db:"product_id" json:"product_id"`
+	// StorageKey locates the original in the storage backend
+	StorageKey  string     `db:"storage_key" json:"-"`
+	URL         string     `db:"url" json:"url"`
+	ContentType string     `db:"content_type" json:"content_type"`
+	Size        int64      `db:"size_bytes" json:"size"`
+	Width       int        `db:"width" json:"width"`
+	Height      int        `db:"height" json:"height"`
+	AltText     *string    `db:"alt_text" json:"alt_text,omitempty"`
+	Position    int        `db:"position" json:"position"`
+	Thumbnails  Thumbnails `db:"thumbnails" json:"thumbnails"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+}
+
+// Thumbnail is a downscaled copy of an image at a fixed width
+type Thumbnail struct {
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	URL    string `json:"url"`
+}
+
+// Thumbnails are stored in product_images.thumbnails as JSONB, narrowest
+// first
+type Thumbnails []Thumbnail
+
+// Value implements driver.Valuer.
+func (t Thumbnails) Value() (driver.Value, error) {
+	if t == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(t)
+}
+
+// Scan implements sql.Scanner.
+func (t *Thumbnails) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, t)
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	case nil:
+		*t = Thumbnails{}
+		return nil
+	default:
+		return errors.New("unsupported type for thumbnails")
+	}
+}
@@ -0,0 +1,386 @@
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency of the price columns, which store amounts
+// without a currency
+const DefaultCurrency = "UAH"
+
+// Money errors
+var (
+	ErrInvalidAmount    = errors.New("invalid money amount")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+// currencyExponents holds the ISO 4217 minor unit digits of the currencies
+// that differ from the usual two
+var currencyExponents = map[string]int{
+	"JPY": 0,
+	"KRW": 0,
+	"BHD": 3,
+	"KWD": 3,
+}
+
+// Exponent returns the number of minor unit digits of an ISO 4217 currency
+func Exponent(currency string) int {
+	if e, ok := currencyExponents[currency]; ok {
+		return e
+	}
+	return 2
+}
+
+// RoundingMode decides how amounts that fall between two minor units are
+// rounded
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds halves away from zero, 0.125 -> 0.13
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven rounds halves to the even neighbour (banker's rounding),
+	// 0.125 -> 0.12, which does not bias sums of many rounded values
+	RoundHalfEven
+)
+
+// Money is an exact amount in the minor units of Currency, e.g. 1999 UAH
+// is 19.99 грн. The zero value is not a valid price and is treated as
+// missing by validation.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// NewMoney returns amount minor units of currency
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney parses a decimal such as "19.99" or "-5" in currency. More
+// fraction digits than the currency has are an error, nothing is rounded.
+func ParseMoney(s, currency string) (Money, error) {
+	amount, err := parseMinor(s, Exponent(currency), nil)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// parseMinor converts the decimal s to minor units with exp fraction digits.
+// Extra digits are rounded with mode, or rejected if mode is nil.
+func parseMinor(s string, exp int, mode *RoundingMode) (int64, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok || strings.ContainsAny(s, "eE/") {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	r.Mul(r, new(big.Rat).SetInt(pow10(exp)))
+	if !r.IsInt() && mode == nil {
+		return 0, fmt.Errorf("%w: %q has more than %d decimals", ErrInvalidAmount, s, exp)
+	}
+	m := RoundHalfUp
+	if mode != nil {
+		m = *mode
+	}
+	n := roundRat(r, m)
+	if !n.IsInt64() {
+		return 0, fmt.Errorf("%w: %q is out of range", ErrInvalidAmount, s)
+	}
+	return n.Int64(), nil
+}
+
+func pow10(exp int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)
+}
+
+// roundRat rounds r to an integer with mode
+func roundRat(r *big.Rat, mode RoundingMode) *big.Int {
+	num, den := r.Num(), r.Denom()
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() == 0 {
+		return q
+	}
+	// |2*rem| vs den decides below, at or above half
+	twice := new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2))
+	c := twice.Cmp(den)
+	away := c > 0 || (c == 0 && (mode == RoundHalfUp || q.Bit(0) == 1))
+	if away {
+		if num.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+// Decimal formats m as a plain decimal with the currency's minor digits,
+// e.g. "19.99"
+func (m Money) Decimal() string {
+	exp := Exponent(m.Currency)
+	s := strconv.FormatInt(m.Amount, 10)
+	if exp == 0 {
+		return s
+	}
+	neg := m.Amount < 0
+	if neg {
+		s = s[1:]
+	}
+	if len(s) <= exp {
+		s = strings.Repeat("0", exp-len(s)+1) + s
+	}
+	s = s[:len(s)-exp] + "." + s[len(s)-exp:]
+	if neg {
+		s = "-" + s
+	}
+	return s
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// ValidationNumber implements validation.Numeric, so that rules such as
+// gt=0 apply to the amount in major units
+func (m Money) ValidationNumber() float64 {
+	f, _ := new(big.Rat).SetFrac(big.NewInt(m.Amount), pow10(Exponent(m.Currency))).Float64()
+	return f
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Cmp compares m and o, which must be in the same currency
+func (m Money) Cmp(o Money) (int, error) {
+	if m.Currency != o.Currency {
+		return 0, ErrCurrencyMismatch
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Add returns m + o. Both must be in the same currency.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+// Sub returns m - o. Both must be in the same currency.
+func (m Money) Sub(o Money) (Money, error) {
+	return m.Add(o.Neg())
+}
+
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+// Mul returns m times quantity, e.g. the total of a cart line
+func (m Money) Mul(quantity int) Money {
+	return Money{Amount: m.Amount * int64(quantity), Currency: m.Currency}
+}
+
+// MulRat returns m * num / den rounded to a minor unit with mode
+func (m Money) MulRat(num, den int64, mode RoundingMode) Money {
+	r := new(big.Rat).SetFrac(big.NewInt(num), big.NewInt(den))
+	r.Mul(r, new(big.Rat).SetInt64(m.Amount))
+	return Money{Amount: roundRat(r, mode).Int64(), Currency: m.Currency}
+}
+
+// Percent returns the given share of m in basis points (1% = 100), e.g.
+// the VAT of a net price or the size of a discount
+func (m Money) Percent(basisPoints int64, mode RoundingMode) Money {
+	return m.MulRat(basisPoints, 10000, mode)
+}
+
+// Discount returns m reduced by basisPoints, the rounded discount is
+// subtracted so it never exceeds the stated percentage by more than half a
+// minor unit
+func (m Money) Discount(basisPoints int64, mode RoundingMode) Money {
+	return Money{Amount: m.Amount - m.Percent(basisPoints, mode).Amount, Currency: m.Currency}
+}
+
+// WithTax returns m plus basisPoints of tax
+func (m Money) WithTax(basisPoints int64, mode RoundingMode) Money {
+	return Money{Amount: m.Amount + m.Percent(basisPoints, mode).Amount, Currency: m.Currency}
+}
+
+// Convert returns m in currency, where rate is the price of one unit of
+// currency in m's currency. The result is rounded to currency's minor unit
+// with mode.
+func (m Money) Convert(currency string, rate Rate, mode RoundingMode) Money {
+	// amount / 10^from / rate * 10^to
+	r := new(big.Rat).SetFrac(big.NewInt(m.Amount), pow10(Exponent(m.Currency)))
+	r.Quo(r, rate.Rat())
+	r.Mul(r, new(big.Rat).SetInt(pow10(Exponent(currency))))
+	return Money{Amount: roundRat(r, mode).Int64(), Currency: currency}
+}
+
+// SumMoney adds up amounts in currency; an empty list is zero currency
+func SumMoney(currency string, amounts ...Money) (Money, error) {
+	total := Money{Currency: currency}
+	for _, a := range amounts {
+		var err error
+		if total, err = total.Add(a); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// moneyJSON is the wire format. Amount is a decimal string so clients do
+// not lose cents to binary floats.
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Decimal(), Currency: m.Currency})
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Besides the object form a bare
+// number or decimal string in DefaultCurrency is accepted, as sent by
+// clients before prices had a currency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil
+	}
+	parsed, err := ParseMoneyJSON(data, DefaultCurrency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// ParseMoneyJSON parses an amount in the JSON forms UnmarshalJSON accepts.
+// A bare number or string is taken in currency, so that it is split into
+// that currency's minor units rather than DefaultCurrency's.
+func ParseMoneyJSON(data []byte, currency string) (Money, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return Money{}, ErrInvalidAmount
+	}
+
+	var amount string
+	switch data[0] {
+	case '{':
+		var v struct {
+			Amount   json.Number `json:"amount"`
+			Currency string      `json:"currency"`
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&v); err != nil {
+			return Money{}, err
+		}
+		amount = v.Amount.String()
+		if v.Currency != "" {
+			currency = strings.ToUpper(v.Currency)
+		}
+	case '"':
+		if err := json.Unmarshal(data, &amount); err != nil {
+			return Money{}, err
+		}
+	default:
+		amount = string(data)
+	}
+	if len(currency) != 3 {
+		return Money{}, fmt.Errorf("%w: unknown currency %q", ErrInvalidAmount, currency)
+	}
+	return ParseMoney(amount, currency)
+}
+
+// Value implements driver.Valuer. The amount is written as a decimal string,
+// which PostgreSQL converts to NUMERIC exactly.
+func (m Money) Value() (driver.Value, error) {
+	return m.Decimal(), nil
+}
+
+// Scan implements sql.Scanner for NUMERIC columns. The currency is kept if
+// already set, otherwise DefaultCurrency; digits beyond the currency's
+// minor unit, e.g. from AVG, are rounded half even. Columns in a currency
+// stored elsewhere in the row are scanned into Decimal instead.
+func (m *Money) Scan(src interface{}) error {
+	var d Decimal
+	if err := d.Scan(src); err != nil {
+		return err
+	}
+	if src == nil {
+		*m = Money{}
+		return nil
+	}
+
+	currency := m.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	parsed, err := d.In(currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Decimal is a NUMERIC amount read as text, for columns whose currency is
+// only known from another column, e.g. order totals. Converting it with In
+// once the currency is known splits it into the right minor units.
+type Decimal string
+
+// Scan implements sql.Scanner.
+func (d *Decimal) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		*d = Decimal(v)
+	case string:
+		*d = Decimal(v)
+	case int64:
+		*d = Decimal(strconv.FormatInt(v, 10))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return ErrInvalidAmount
+		}
+		*d = Decimal(strconv.FormatFloat(v, 'f', -1, 64))
+	case nil:
+		*d = ""
+	default:
+		return errors.New("unsupported type for money")
+	}
+	return nil
+}
+
+// In returns d in currency. Digits beyond the currency's minor unit are
+// rounded half even, like in Money.Scan.
+func (d Decimal) In(currency string) (Money, error) {
+	mode := RoundHalfEven
+	amount, err := parseMinor(string(d), Exponent(currency), &mode)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
@@ -0,0 +1,303 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in       string
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{in: "19.99", currency: "UAH", want: 1999},
+		{in: "-5", currency: "UAH", want: -500},
+		{in: " 7.5 ", currency: "UAH", want: 750},
+		{in: "0", currency: "UAH", want: 0},
+		{in: "100", currency: "JPY", want: 100},
+		{in: "1.234", currency: "KWD", want: 1234},
+		{in: "0.001", currency: "BHD", want: 1},
+
+		// Extra digits are rejected, not rounded
+		{in: "19.999", currency: "UAH", wantErr: true},
+		{in: "1.5", currency: "JPY", wantErr: true},
+		{in: "1.2345", currency: "BHD", wantErr: true},
+
+		{in: "", currency: "UAH", wantErr: true},
+		{in: "abc", currency: "UAH", wantErr: true},
+		{in: "1e3", currency: "UAH", wantErr: true},
+		{in: "1/2", currency: "UAH", wantErr: true},
+		{in: "99999999999999999999", currency: "UAH", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in, tt.currency)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidAmount) {
+				t.Errorf("ParseMoney(%q, %s) error = %v, want ErrInvalidAmount", tt.in, tt.currency, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMoney(%q, %s): %v", tt.in, tt.currency, err)
+			continue
+		}
+		if want := NewMoney(tt.want, tt.currency); got != want {
+			t.Errorf("ParseMoney(%q, %s) = %+v, want %+v", tt.in, tt.currency, got, want)
+		}
+	}
+}
+
+func TestDecimalIn(t *testing.T) {
+	tests := []struct {
+		in       Decimal
+		currency string
+		want     int64
+	}{
+		{in: "19.99", currency: "UAH", want: 1999},
+		{in: "19.990", currency: "UAH", want: 1999},
+
+		// Ties go to the even neighbour
+		{in: "0.125", currency: "UAH", want: 12},
+		{in: "0.135", currency: "UAH", want: 14},
+		{in: "-0.125", currency: "UAH", want: -12},
+		{in: "0.1251", currency: "UAH", want: 13},
+		{in: "2.5", currency: "JPY", want: 2},
+		{in: "3.5", currency: "JPY", want: 4},
+		{in: "12.5", currency: "KRW", want: 12},
+		{in: "1.2345", currency: "KWD", want: 1234},
+		{in: "1.2355", currency: "BHD", want: 1236},
+
+		// A 3-decimal amount keeps its last digit
+		{in: "1.005", currency: "KWD", want: 1005},
+	}
+	for _, tt := range tests {
+		got, err := tt.in.In(tt.currency)
+		if err != nil {
+			t.Errorf("Decimal(%q).In(%s): %v", tt.in, tt.currency, err)
+			continue
+		}
+		if want := NewMoney(tt.want, tt.currency); got != want {
+			t.Errorf("Decimal(%q).In(%s) = %+v, want %+v", tt.in, tt.currency, got, want)
+		}
+	}
+
+	for _, in := range []Decimal{"", "abc", "1e2"} {
+		if _, err := in.In("UAH"); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("Decimal(%q).In(UAH) error = %v, want ErrInvalidAmount", in, err)
+		}
+	}
+}
+
+func TestMoneyScan(t *testing.T) {
+	tests := []struct {
+		name     string
+		currency string
+		src      interface{}
+		want     Money
+		wantErr  bool
+	}{
+		{name: "bytes", src: []byte("19.99"), want: NewMoney(1999, "UAH")},
+		{name: "string", src: "0.5", want: NewMoney(50, "UAH")},
+		{name: "int", src: int64(5), want: NewMoney(500, "UAH")},
+		{name: "float", src: 1.5, want: NewMoney(150, "UAH")},
+		{name: "tie rounds to even", src: []byte("19.995"), want: NewMoney(2000, "UAH")},
+		{name: "tie stays even", src: []byte("19.985"), want: NewMoney(1998, "UAH")},
+		{name: "currency kept", currency: "KWD", src: []byte("1.0005"), want: NewMoney(1000, "KWD")},
+		{name: "no minor units", currency: "JPY", src: []byte("150.5"), want: NewMoney(150, "JPY")},
+		{name: "null", currency: "KWD", src: nil, want: Money{}},
+		{name: "NaN", src: math.NaN(), wantErr: true},
+		{name: "garbage", src: []byte("abc"), wantErr: true},
+		{name: "unsupported type", src: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		m := Money{Currency: tt.currency}
+		err := m.Scan(tt.src)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: Scan(%v) = %+v, want error", tt.name, tt.src, m)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Scan(%v): %v", tt.name, tt.src, err)
+			continue
+		}
+		if m != tt.want {
+			t.Errorf("%s: Scan(%v) = %+v, want %+v", tt.name, tt.src, m, tt.want)
+		}
+	}
+}
+
+func TestMoneyDecimal(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{m: NewMoney(1999, "UAH"), want: "19.99"},
+		{m: NewMoney(-1999, "UAH"), want: "-19.99"},
+		{m: NewMoney(5, "UAH"), want: "0.05"},
+		{m: NewMoney(-5, "UAH"), want: "-0.05"},
+		{m: NewMoney(0, "UAH"), want: "0.00"},
+		{m: NewMoney(100, "JPY"), want: "100"},
+		{m: NewMoney(1234, "KWD"), want: "1.234"},
+		{m: NewMoney(7, "BHD"), want: "0.007"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.Decimal(); got != tt.want {
+			t.Errorf("%+v.Decimal() = %q, want %q", tt.m, got, tt.want)
+		}
+		// Decimal is what Value writes, so it must read back unchanged
+		back, err := ParseMoney(tt.m.Decimal(), tt.m.Currency)
+		if err != nil || back != tt.m {
+			t.Errorf("ParseMoney(%q, %s) = %+v, %v, want %+v", tt.m.Decimal(), tt.m.Currency, back, err, tt.m)
+		}
+	}
+}
+
+func TestMoneyPercent(t *testing.T) {
+	tests := []struct {
+		name     string
+		m        Money
+		bp       int64
+		halfUp   int64
+		halfEven int64
+	}{
+		{name: "exact", m: NewMoney(1250, "UAH"), bp: 1000, halfUp: 125, halfEven: 125},
+		{name: "tie", m: NewMoney(125, "UAH"), bp: 1000, halfUp: 13, halfEven: 12},
+		{name: "odd tie", m: NewMoney(135, "UAH"), bp: 1000, halfUp: 14, halfEven: 14},
+		{name: "negative tie", m: NewMoney(-125, "UAH"), bp: 1000, halfUp: -13, halfEven: -12},
+		{name: "below half", m: NewMoney(1999, "UAH"), bp: 2000, halfUp: 400, halfEven: 400},
+		{name: "no minor units", m: NewMoney(105, "JPY"), bp: 1000, halfUp: 11, halfEven: 10},
+		{name: "three decimals", m: NewMoney(1005, "KWD"), bp: 5000, halfUp: 503, halfEven: 502},
+	}
+	for _, tt := range tests {
+		if got := tt.m.Percent(tt.bp, RoundHalfUp); got != NewMoney(tt.halfUp, tt.m.Currency) {
+			t.Errorf("%s: Percent half up = %+v, want %d", tt.name, got, tt.halfUp)
+		}
+		if got := tt.m.Percent(tt.bp, RoundHalfEven); got != NewMoney(tt.halfEven, tt.m.Currency) {
+			t.Errorf("%s: Percent half even = %+v, want %d", tt.name, got, tt.halfEven)
+		}
+	}
+}
+
+func TestMoneyLineHelpers(t *testing.T) {
+	price := NewMoney(125, "UAH")
+
+	if got := price.Mul(3); got != NewMoney(375, "UAH") {
+		t.Errorf("Mul(3) = %+v, want 375", got)
+	}
+	// The rounded discount is subtracted, so the tie goes the other way
+	if got := price.Discount(1000, RoundHalfUp); got != NewMoney(112, "UAH") {
+		t.Errorf("Discount half up = %+v, want 112", got)
+	}
+	if got := price.Discount(1000, RoundHalfEven); got != NewMoney(113, "UAH") {
+		t.Errorf("Discount half even = %+v, want 113", got)
+	}
+	if got := price.WithTax(1000, RoundHalfUp); got != NewMoney(138, "UAH") {
+		t.Errorf("WithTax half up = %+v, want 138", got)
+	}
+	if got := price.WithTax(1000, RoundHalfEven); got != NewMoney(137, "UAH") {
+		t.Errorf("WithTax half even = %+v, want 137", got)
+	}
+	if got := NewMoney(100, "UAH").MulRat(1, 3, RoundHalfUp); got != NewMoney(33, "UAH") {
+		t.Errorf("MulRat(1, 3) = %+v, want 33", got)
+	}
+
+	total, err := SumMoney("UAH", price, price.Mul(2))
+	if err != nil || total != NewMoney(375, "UAH") {
+		t.Errorf("SumMoney = %+v, %v, want 375", total, err)
+	}
+	if _, err := SumMoney("UAH", price, NewMoney(1, "USD")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("SumMoney of mixed currencies error = %v, want ErrCurrencyMismatch", err)
+	}
+	if _, err := price.Cmp(NewMoney(1, "USD")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Cmp of mixed currencies error = %v, want ErrCurrencyMismatch", err)
+	}
+}
+
+func TestMoneyConvert(t *testing.T) {
+	tests := []struct {
+		name     string
+		m        Money
+		currency string
+		rate     string
+		mode     RoundingMode
+		want     int64
+	}{
+		{name: "exact", m: NewMoney(10000, "UAH"), currency: "USD", rate: "40", mode: RoundHalfEven, want: 250},
+		{name: "to no minor units", m: NewMoney(10000, "UAH"), currency: "JPY", rate: "0.25", mode: RoundHalfEven, want: 400},
+		{name: "to three decimals", m: NewMoney(10000, "UAH"), currency: "KWD", rate: "128", mode: RoundHalfEven, want: 781},
+		{name: "tie half up", m: NewMoney(100, "UAH"), currency: "USD", rate: "8", mode: RoundHalfUp, want: 13},
+		{name: "tie half even", m: NewMoney(100, "UAH"), currency: "USD", rate: "8", mode: RoundHalfEven, want: 12},
+	}
+	for _, tt := range tests {
+		rate, err := ParseRate(tt.rate)
+		if err != nil {
+			t.Fatalf("ParseRate(%q): %v", tt.rate, err)
+		}
+		got := tt.m.Convert(tt.currency, rate, tt.mode)
+		if want := NewMoney(tt.want, tt.currency); got != want {
+			t.Errorf("%s: Convert = %+v, want %+v", tt.name, got, want)
+		}
+	}
+}
+
+func TestParseMoneyJSON(t *testing.T) {
+	tests := []struct {
+		in       string
+		currency string
+		want     Money
+		wantErr  bool
+	}{
+		{in: `{"amount":"19.99","currency":"UAH"}`, currency: "UAH", want: NewMoney(1999, "UAH")},
+		{in: `{"amount":1.234,"currency":"kwd"}`, currency: "UAH", want: NewMoney(1234, "KWD")},
+		{in: `"19.99"`, currency: "UAH", want: NewMoney(1999, "UAH")},
+		{in: `19.99`, currency: "UAH", want: NewMoney(1999, "UAH")},
+		// A bare amount is split into the minor units of currency
+		{in: `5`, currency: "JPY", want: NewMoney(5, "JPY")},
+		{in: `"1.234"`, currency: "BHD", want: NewMoney(1234, "BHD")},
+
+		{in: `19.999`, currency: "UAH", wantErr: true},
+		{in: `"1.5"`, currency: "JPY", wantErr: true},
+		{in: `null`, currency: "UAH", wantErr: true},
+		{in: ``, currency: "UAH", wantErr: true},
+		{in: `{"amount":"1","currency":"EU"}`, currency: "UAH", wantErr: true},
+		{in: `{"amount":`, currency: "UAH", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoneyJSON([]byte(tt.in), tt.currency)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseMoneyJSON(%s, %s) = %+v, want error", tt.in, tt.currency, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMoneyJSON(%s, %s): %v", tt.in, tt.currency, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMoneyJSON(%s, %s) = %+v, want %+v", tt.in, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyValidationNumber(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want float64
+	}{
+		{m: NewMoney(1999, "UAH"), want: 19.99},
+		{m: NewMoney(100, "JPY"), want: 100},
+		{m: NewMoney(1234, "KWD"), want: 1.234},
+		{m: NewMoney(-1, "UAH"), want: -0.01},
+	}
+	for _, tt := range tests {
+		if got := tt.m.ValidationNumber(); got != tt.want {
+			t.Errorf("%+v.ValidationNumber() = %v, want %v", tt.m, got, tt.want)
+		}
+	}
+}
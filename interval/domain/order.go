@@ -1,23 +1,100 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const (
+	OrderStatusPending   = "pending"
+	OrderStatusConfirmed = "confirmed"
+	OrderStatusShipped   = "shipped"
+	OrderStatusDelivered = "delivered"
+	OrderStatusCancelled = "cancelled"
+)
+
+const (
+	PaymentMethodCash = "cash"
+	PaymentMethodCard = "card"
+)
+
 type Order struct {
-	ID         uuid.UUID         `json:"id"`
-	UserID     uuid.UUID         `json:"user_id"`
-	Items      map[uuid.UUID]int `json:"items"` // product ID to quantity
-	TotalPrice float64           `json:"total_price"`
-	Status     string            `json:"status"`
-	CreatedAt  time.Time         `json:"created_at"`
-	UpdatedAt  time.Time         `json:"updated_at"`
+	ID         uuid.UUID    `db:"id" json:"id"`
+	UserID     uuid.UUID    `db:"user_id" json:"user_id"`
+	Items      []*OrderItem `db:"-" json:"items"`
+	TotalPrice Money        `db:"-" json:"total_price"`
+	// Currency and ExchangeRate are fixed at checkout; ExchangeRate is the
+	// price of one unit of Currency in DefaultCurrency. TotalPrice and item
+	// prices are in Currency and filled by the repository, since the NUMERIC
+	// columns do not know it.
+	Currency        string          `db:"currency" json:"currency"`
+	ExchangeRate    Rate            `db:"exchange_rate" json:"exchange_rate"`
+	Status          string          `db:"status" json:"status"`
+	ShippingAddress ShippingAddress `db:"shipping_address" json:"shipping_address"`
+	PaymentMethod   string          `db:"payment_method" json:"payment_method"`
+	CreatedAt       time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// OrderItem is a product line of an order with the price snapshotted at checkout
+type OrderItem struct {
+	ID        uuid.UUID  `db:"id" json:"id"`
+	OrderID   uuid.UUID  `db:"order_id" json:"order_id"`
+	ProductID uuid.UUID  `db:"product_id" json:"product_id"`
+	VariantID *uuid.UUID `db:"variant_id" json:"variant_id,omitempty"`
+	Quantity  int        `db:"quantity" json:"quantity"`
+	Price     Money      `db:"-" json:"price"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+}
+
+// ShippingAddress is stored in orders.shipping_address as JSONB
+type ShippingAddress struct {
+	FullName   string `json:"full_name" validate:"required,max=200"`
+	Phone      string `json:"phone,omitempty" validate:"omitempty,max=50"`
+	Street     string `json:"street" validate:"required,max=255"`
+	City       string `json:"city" validate:"required,max=100"`
+	PostalCode string `json:"postal_code,omitempty" validate:"omitempty,max=20"`
+	Country    string `json:"country" validate:"required,max=100"`
+}
+
+// Value implements driver.Valuer.
+func (a ShippingAddress) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+// Scan implements sql.Scanner.
+func (a *ShippingAddress) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	case nil:
+		*a = ShippingAddress{}
+		return nil
+	default:
+		return errors.New("unsupported type for shipping_address")
+	}
+}
+
+// OrderStatusChange is a row of order_status_history
+type OrderStatusChange struct {
+	ID         uuid.UUID  `db:"id" json:"id"`
+	OrderID    uuid.UUID  `db:"order_id" json:"order_id"`
+	FromStatus *string    `db:"from_status" json:"from_status"`
+	ToStatus   string     `db:"to_status" json:"to_status"`
+	ChangedBy  *uuid.UUID `db:"changed_by" json:"changed_by"`
+	ChangedAt  time.Time  `db:"changed_at" json:"changed_at"`
 }
 
 type OrderFilter struct {
 	UserID *uuid.UUID
 	Limit  int
 	Offset int
+	// Cursor switches from Offset to keyset pagination on (created_at, id)
+	Cursor *Keyset
 }
@@ -0,0 +1,13 @@
+package models
+
+import "github.com/google/uuid"
+
+// Keyset positions a listing next to a row in (sort key, id) order. It is
+// used instead of an offset, so pages stay stable while rows are inserted.
+type Keyset struct {
+	// Key is the sort key of the boundary row, formatted as text
+	Key string
+	ID  uuid.UUID
+	// Backward returns the rows before the boundary instead of after it
+	Backward bool
+}
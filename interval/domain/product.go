@@ -10,20 +10,70 @@ type Product struct {
 	ID          uuid.UUID  `db:"id" json:"id"`
 	Name        string     `db:"name" json:"name"`
 	Description *string    `db:"description" json:"description"`
-	Price       float64    `db:"price" json:"price"`
+	Price       Money      `db:"price" json:"price"`
 	Stock       int        `db:"stock" json:"stock"`
 	CategoryID  *uuid.UUID `db:"category_id" json:"category_id,omitempty"`
 	ImageURL    *string    `db:"image_url" json:"image_url,omitempty"`
+	Version     int        `db:"version" json:"version"`
 	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
 	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
+	DeletedAt   *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+	// Variants, the Options matrix, Attributes and Images are only loaded for a
+	// single product
+	Variants   []*ProductVariant   `db:"-" json:"variants,omitempty"`
+	Options    []VariantOption     `db:"-" json:"options,omitempty"`
+	Attributes []*ProductAttribute `db:"-" json:"attributes,omitempty"`
+	Images     []*ProductImage     `db:"-" json:"images,omitempty"`
+}
+
+// HasVariants reports whether the product is sold per variant. Only valid
+// when Variants were loaded.
+func (p *Product) HasVariants() bool {
+	return len(p.Variants) > 0
+}
+
+// Variant returns the loaded variant with id, or nil
+func (p *Product) Variant(id uuid.UUID) *ProductVariant {
+	for _, v := range p.Variants {
+		if v.ID == id {
+			return v
+		}
+	}
+	return nil
+}
+
+// IsArchived reports whether the product was soft-deleted
+func (p *Product) IsArchived() bool {
+	return p.DeletedAt != nil
 }
 
 type ListFilter struct {
 	CategoryID *uuid.UUID
-	MinPrice   *float64
-	MaxPrice   *float64
-	Search     string
-	Limit      int
-	Offset     int
-	OrderBy    string
+	// IncludeSubcategories extends CategoryID to all its descendants
+	IncludeSubcategories bool
+	// IncludeArchived also returns soft-deleted products
+	IncludeArchived bool
+	MinPrice        *Money
+	MaxPrice        *Money
+	// InStock filters on stock > 0 (true) or stock = 0 (false)
+	InStock *bool
+	// Attributes must all match, see AttributeFilter
+	Attributes []AttributeFilter
+	// Search is a full text query in websearch_to_tsquery syntax, e.g.
+	// `"red shirt" -cotton`
+	Search  string
+	Limit   int
+	Offset  int
+	OrderBy string
+	// Cursor switches from Offset to keyset pagination
+	Cursor *Keyset
+}
+
+// ProductSearchResult is a product matched by a full text search. The
+// highlights wrap matched words in <mark> tags.
+type ProductSearchResult struct {
+	Product
+	Rank          float64 `db:"rank" json:"rank"`
+	NameHighlight string  `db:"name_highlight" json:"name_highlight"`
+	Snippet       string  `db:"snippet" json:"snippet"`
 }
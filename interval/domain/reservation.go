@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ReservationOwnerCart  = "cart"
+	ReservationOwnerOrder = "order"
+)
+
+const (
+	ReservationActive   = "active"
+	ReservationReleased = "released"
+	ReservationExpired  = "expired"
+)
+
+// StockReservation is stock taken out of products.stock, or out of a
+// variant's stock, and held for a cart (with an expiry) or an order (until
+// it is cancelled).
+type StockReservation struct {
+	ID         uuid.UUID  `db:"id" json:"id"`
+	ProductID  uuid.UUID  `db:"product_id" json:"product_id"`
+	VariantID  *uuid.UUID `db:"variant_id" json:"variant_id,omitempty"`
+	OwnerType  string     `db:"owner_type" json:"owner_type"`
+	OwnerID    uuid.UUID  `db:"owner_id" json:"owner_id"`
+	Quantity   int        `db:"quantity" json:"quantity"`
+	Status     string     `db:"status" json:"status"`
+	ExpiresAt  *time.Time `db:"expires_at" json:"expires_at,omitempty"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	ReleasedAt *time.Time `db:"released_at" json:"released_at,omitempty"`
+}
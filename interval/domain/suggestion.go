@@ -0,0 +1,18 @@
+package models
+
+import "github.com/google/uuid"
+
+// Suggestion kinds
+const (
+	SuggestionProduct  = "product"
+	SuggestionCategory = "category"
+)
+
+// Suggestion is an autocomplete entry for a partially typed search query.
+// Score is 1 for prefix matches and the trigram word similarity otherwise.
+type Suggestion struct {
+	Kind  string    `db:"kind" json:"kind"`
+	ID    uuid.UUID `db:"id" json:"id"`
+	Text  string    `db:"text" json:"text"`
+	Score float64   `db:"score" json:"score"`
+}
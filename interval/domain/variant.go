@@ -0,0 +1,104 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProductVariant is a purchasable version of a product, e.g. a T-Shirt in
+// size M and black. A product with variants is stocked and sold per variant;
+// its products.stock is the total of the variants.
+type ProductVariant struct {
+	ID        uuid.UUID      `db:"id" json:"id"`
+	ProductID uuid.UUID      `db:"product_id" json:"product_id"`
+	SKU       string         `db:"sku" json:"sku"`
+	Options   VariantOptions `db:"options" json:"options"`
+	// Price overrides the product price when set
+	Price     *Money    `db:"price" json:"price,omitempty"`
+	Stock     int       `db:"stock" json:"stock"`
+	Version   int       `db:"version" json:"version"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// PriceOf returns the price of the variant of product
+func (v *ProductVariant) PriceOf(product *Product) Money {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return product.Price
+}
+
+// VariantOptions are the option values of a variant by option name, e.g.
+// {"size": "M", "color": "black"}. Stored in product_variants.options as JSONB.
+type VariantOptions map[string]string
+
+// Value implements driver.Valuer.
+func (o VariantOptions) Value() (driver.Value, error) {
+	if o == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(o)
+}
+
+// Scan implements sql.Scanner.
+func (o *VariantOptions) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, o)
+	case string:
+		return json.Unmarshal([]byte(v), o)
+	case nil:
+		*o = VariantOptions{}
+		return nil
+	default:
+		return errors.New("unsupported type for variant options")
+	}
+}
+
+// VariantOption is one axis of the variant matrix with the values offered
+// by at least one variant, e.g. size: S, M, L
+type VariantOption struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// VariantMatrix collects the option axes of variants. Names are sorted;
+// values keep the order of the first variant offering them.
+func VariantMatrix(variants []*ProductVariant) []VariantOption {
+	values := map[string][]string{}
+	seen := map[string]map[string]bool{}
+	for _, v := range variants {
+		keys := make([]string, 0, len(v.Options))
+		for name := range v.Options {
+			keys = append(keys, name)
+		}
+		sort.Strings(keys)
+		for _, name := range keys {
+			value := v.Options[name]
+			if seen[name] == nil {
+				seen[name] = map[string]bool{}
+			}
+			if !seen[name][value] {
+				seen[name][value] = true
+				values[name] = append(values[name], value)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	matrix := make([]VariantOption, 0, len(names))
+	for _, name := range names {
+		matrix = append(matrix, VariantOption{Name: name, Values: values[name]})
+	}
+	return matrix
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	attributeSrv "github.com/nightx1x/ecommerce/interval/service/attribute"
+	policySrv "github.com/nightx1x/ecommerce/interval/service/policy"
+)
+
+type AttributeHandler struct {
+	AttributeSrv attributeSrv.AttributeService
+	Guard        *Guard
+}
+
+func NewAttributeHandler(srv attributeSrv.AttributeService, guard *Guard) *AttributeHandler {
+	return &AttributeHandler{AttributeSrv: srv, Guard: guard}
+}
+
+func (h *AttributeHandler) RegisterRoutes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Get("/attributes", h.ListAttributes)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.Guard.Authenticate)
+		r.Use(h.Guard.Require(policySrv.PermAdminAccess))
+		r.Get("/admin/attributes", h.AdminListAttributes)
+		r.With(h.Guard.Require(policySrv.PermProductsWrite)).Post("/admin/attributes", h.CreateAttribute)
+		r.With(h.Guard.Require(policySrv.PermProductsWrite)).Delete("/admin/attributes/{id}", h.DeleteAttribute)
+		r.With(h.Guard.Require(policySrv.PermProductsWrite)).Put("/admin/products/{id}/attributes", h.SetProductAttributes)
+	})
+}
+
+// ListAttributes lists the filterable attributes, the ones that can be used
+// in attr.<code> filters of /products
+func (h *AttributeHandler) ListAttributes(w http.ResponseWriter, r *http.Request) {
+	attributes, err := h.AttributeSrv.ListAttributes(r.Context(), true)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, attributes)
+}
+
+func (h *AttributeHandler) AdminListAttributes(w http.ResponseWriter, r *http.Request) {
+	attributes, err := h.AttributeSrv.ListAttributes(r.Context(), false)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, attributes)
+}
+
+func (h *AttributeHandler) CreateAttribute(w http.ResponseWriter, r *http.Request) {
+	var req attributeSrv.CreateAttributeRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	attribute, err := h.AttributeSrv.CreateAttribute(r.Context(), req)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, attribute)
+}
+
+func (h *AttributeHandler) DeleteAttribute(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, r, invalidID("attribute"))
+		return
+	}
+
+	if err := h.AttributeSrv.DeleteAttribute(r.Context(), id); err != nil {
+		respondError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetProductAttributes replaces all attribute values of a product
+func (h *AttributeHandler) SetProductAttributes(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, r, invalidID("product"))
+		return
+	}
+
+	var req attributeSrv.SetProductAttributesRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	attributes, err := h.AttributeSrv.SetProductAttributes(r.Context(), id, req)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, attributes)
+}
@@ -0,0 +1,83 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	authSrv "github.com/nightx1x/ecommerce/interval/service/auth"
+)
+
+type AuthHandler struct {
+	AuthSrv authSrv.AuthService
+}
+
+func NewAuthHandler(srv authSrv.AuthService) *AuthHandler {
+	return &AuthHandler{AuthSrv: srv}
+}
+
+func (h *AuthHandler) RegisterRoutes(r chi.Router) {
+	r.Post("/auth/register", h.Register)
+	r.Post("/auth/login", h.Login)
+	r.Post("/auth/refresh", h.Refresh)
+	r.Post("/auth/logout", h.Logout)
+}
+
+// RefreshRequest carries a refresh token for /auth/refresh and /auth/logout
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var req authSrv.RegisterRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	tokens, err := h.AuthSrv.Register(r.Context(), req)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, tokens)
+}
+
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req authSrv.LoginRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	tokens, err := h.AuthSrv.Login(r.Context(), req)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, tokens)
+}
+
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	tokens, err := h.AuthSrv.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, tokens)
+}
+
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.AuthSrv.Logout(r.Context(), req.RefreshToken); err != nil {
+		respondError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,152 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	cartSrv "github.com/nightx1x/ecommerce/interval/service/cart"
+)
+
+type CartHandler struct {
+	CartSrv cartSrv.CartService
+	Guard   *Guard
+}
+
+func NewCartHandler(srv cartSrv.CartService, guard *Guard) *CartHandler {
+	return &CartHandler{CartSrv: srv, Guard: guard}
+}
+
+func (h *CartHandler) RegisterRoutes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(h.Guard.Authenticate)
+		r.Get("/cart", h.GetCart)
+		r.Delete("/cart", h.ClearCart)
+		r.Post("/cart/items", h.AddItem)
+		r.Put("/cart/items/{productID}", h.SetQuantity)
+		r.Delete("/cart/items/{productID}", h.RemoveItem)
+	})
+}
+
+func (h *CartHandler) GetCart(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, errAuthRequired)
+		return
+	}
+
+	cart, err := h.CartSrv.GetCart(r.Context(), userID, requestCurrency(r))
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	varyCurrency(w)
+	respondJSON(w, http.StatusOK, cart)
+}
+
+func (h *CartHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, errAuthRequired)
+		return
+	}
+
+	var req cartSrv.AddItemRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	cart, err := h.CartSrv.AddItem(r.Context(), userID, req, requestCurrency(r))
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	varyCurrency(w)
+	respondJSON(w, http.StatusOK, cart)
+}
+
+func (h *CartHandler) SetQuantity(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, errAuthRequired)
+		return
+	}
+
+	productID, err := uuid.Parse(chi.URLParam(r, "productID"))
+	if err != nil {
+		respondError(w, r, invalidID("product"))
+		return
+	}
+	variantID, ok := variantIDFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	var req cartSrv.SetQuantityRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	cart, err := h.CartSrv.SetQuantity(r.Context(), userID, productID, variantID, req.Quantity, requestCurrency(r))
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	varyCurrency(w)
+	respondJSON(w, http.StatusOK, cart)
+}
+
+func (h *CartHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, errAuthRequired)
+		return
+	}
+
+	productID, err := uuid.Parse(chi.URLParam(r, "productID"))
+	if err != nil {
+		respondError(w, r, invalidID("product"))
+		return
+	}
+	variantID, ok := variantIDFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	cart, err := h.CartSrv.RemoveItem(r.Context(), userID, productID, variantID, requestCurrency(r))
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	varyCurrency(w)
+	respondJSON(w, http.StatusOK, cart)
+}
+
+// variantIDFromQuery reads the optional variant_id parameter that selects a
+// variant line of the product in the path
+func variantIDFromQuery(w http.ResponseWriter, r *http.Request) (*uuid.UUID, bool) {
+	raw := r.URL.Query().Get("variant_id")
+	if raw == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		respondError(w, r, invalidID("variant"))
+		return nil, false
+	}
+	return &id, true
+}
+
+func (h *CartHandler) ClearCart(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, errAuthRequired)
+		return
+	}
+
+	if err := h.CartSrv.ClearCart(r.Context(), userID); err != nil {
+		respondError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
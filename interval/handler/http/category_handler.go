@@ -0,0 +1,133 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	categorySrv "github.com/nightx1x/ecommerce/interval/service/category"
+	policySrv "github.com/nightx1x/ecommerce/interval/service/policy"
+)
+
+type CategoryHandler struct {
+	CategorySrv categorySrv.CategoryService
+	Guard       *Guard
+}
+
+func NewCategoryHandler(srv categorySrv.CategoryService, guard *Guard) *CategoryHandler {
+	return &CategoryHandler{CategorySrv: srv, Guard: guard}
+}
+
+func (h *CategoryHandler) RegisterRoutes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Get("/categories", h.ListCategories)
+		r.Get("/categories/tree", h.GetTree)
+		r.Get("/categories/{id}", h.GetCategory)
+		r.Get("/categories/{id}/breadcrumbs", h.GetBreadcrumbs)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.Guard.Authenticate)
+		r.Use(h.Guard.Require(policySrv.PermAdminAccess))
+		r.With(h.Guard.Require(policySrv.PermCategoriesWrite)).Post("/admin/categories", h.CreateCategory)
+		r.With(h.Guard.Require(policySrv.PermCategoriesWrite)).Put("/admin/categories/{id}", h.UpdateCategory)
+		r.With(h.Guard.Require(policySrv.PermCategoriesWrite)).Delete("/admin/categories/{id}", h.DeleteCategory)
+	})
+}
+
+func (h *CategoryHandler) ListCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.CategorySrv.ListCategories(r.Context())
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, categories)
+}
+
+func (h *CategoryHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, r, invalidID("category"))
+		return
+	}
+
+	category, err := h.CategorySrv.GetCategory(r.Context(), id)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, category)
+}
+
+func (h *CategoryHandler) GetTree(w http.ResponseWriter, r *http.Request) {
+	tree, err := h.CategorySrv.GetTree(r.Context())
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, tree)
+}
+
+func (h *CategoryHandler) GetBreadcrumbs(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, r, invalidID("category"))
+		return
+	}
+
+	breadcrumbs, err := h.CategorySrv.GetBreadcrumbs(r.Context(), id)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, breadcrumbs)
+}
+
+// ADMIN PART
+func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var req categorySrv.CreateCategoryRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	category, err := h.CategorySrv.CreateCategory(r.Context(), req)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, category)
+}
+
+func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, r, invalidID("category"))
+		return
+	}
+
+	var req categorySrv.UpdateCategoryRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	category, err := h.CategorySrv.UpdateCategory(r.Context(), id, req)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, category)
+}
+
+func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, r, invalidID("category"))
+		return
+	}
+
+	if err := h.CategorySrv.DeleteCategory(r.Context(), id); err != nil {
+		respondError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,40 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// requestCurrency returns the currency prices should be shown in: the
+// currency query parameter, else the preferred entry of the Accept-Currency
+// header, e.g. "EUR, USD;q=0.5". Empty means the default currency.
+func requestCurrency(r *http.Request) string {
+	if currency := r.URL.Query().Get("currency"); currency != "" {
+		return currency
+	}
+
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept-Currency"), ",") {
+		code, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		code = strings.TrimSpace(code)
+		if code == "" || code == "*" {
+			continue
+		}
+		q := 1.0
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+			if v, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				q = v
+			}
+		}
+		if q > bestQ {
+			best, bestQ = code, q
+		}
+	}
+	return best
+}
+
+// varyCurrency marks responses whose prices depend on Accept-Currency
+func varyCurrency(w http.ResponseWriter) {
+	w.Header().Add("Vary", "Accept-Currency")
+}
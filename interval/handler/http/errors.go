@@ -0,0 +1,100 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/nightx1x/ecommerce/interval/apperror"
+	orderSrv "github.com/nightx1x/ecommerce/interval/service/order"
+	productSrv "github.com/nightx1x/ecommerce/interval/service/product"
+	"github.com/nightx1x/ecommerce/interval/validation"
+)
+
+// Errors raised by the HTTP layer itself
+var (
+	errInvalidBody  = apperror.BadRequest("invalid_body", "Invalid request body")
+	errAuthRequired = apperror.Unauthorized("authentication_required", "Authentication required")
+	errMissingToken = apperror.Unauthorized("missing_token", "Missing bearer token")
+)
+
+// invalidID is returned for malformed IDs in the path or query
+func invalidID(what string) *apperror.Error {
+	return apperror.BadRequest("invalid_id", "Invalid "+what+" ID")
+}
+
+// invalidParam is returned for malformed query parameters and headers
+func invalidParam(msg string) *apperror.Error {
+	return apperror.BadRequest("invalid_parameter", msg)
+}
+
+// preconditionFailed is returned when an If-Match precondition does not hold
+func preconditionFailed(msg string) *apperror.Error {
+	return apperror.New(http.StatusPreconditionFailed, "precondition_failed", msg)
+}
+
+// ErrorResponse is the envelope of every error returned by the API
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	Code      string                  `json:"code"`
+	Message   string                  `json:"message"`
+	Status    int                     `json:"status"`
+	RequestID string                  `json:"request_id,omitempty"`
+	Fields    []validation.FieldError `json:"fields,omitempty"`
+}
+
+// respondError writes err as an ErrorResponse. Errors that are not an
+// *apperror.Error, or mapped to one below, become a 500 whose cause is
+// logged but not returned.
+func respondError(w http.ResponseWriter, r *http.Request, err error) {
+	appErr := toAppError(err)
+	body := ErrorBody{
+		Code:      appErr.Code,
+		Message:   appErr.Message,
+		Status:    appErr.Status,
+		RequestID: middleware.GetReqID(r.Context()),
+	}
+
+	var validationErr *validation.Error
+	if errors.As(err, &validationErr) {
+		body.Fields = validationErr.Fields
+	}
+	if appErr.Status >= http.StatusInternalServerError {
+		log.Printf("[%s] %s %s: %v", body.RequestID, r.Method, r.URL.Path, err)
+	}
+	if appErr.Status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	}
+	respondJSON(w, appErr.Status, ErrorResponse{Error: body})
+}
+
+// toAppError maps typed service errors to API errors
+func toAppError(err error) *apperror.Error {
+	var validationErr *validation.Error
+	if errors.As(err, &validationErr) {
+		return apperror.New(http.StatusUnprocessableEntity, apperror.CodeValidationFailed, "Validation failed").Wrap(err)
+	}
+	var transitionErr *orderSrv.InvalidTransitionError
+	if errors.As(err, &transitionErr) {
+		return apperror.Conflict("invalid_status_transition", transitionErr.Error()).Wrap(err)
+	}
+	var conflictErr *productSrv.VersionConflictError
+	if errors.As(err, &conflictErr) {
+		return apperror.Conflict("version_conflict", conflictErr.Error()).Wrap(err)
+	}
+	return apperror.From(err)
+}
+
+// NotFound responds to requests that match no route
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	respondError(w, r, apperror.NotFound("route_not_found", "Route not found"))
+}
+
+// MethodNotAllowed responds to requests with a method the route lacks
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	respondError(w, r, apperror.New(http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed"))
+}
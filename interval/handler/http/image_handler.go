@@ -0,0 +1,141 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/nightx1x/ecommerce/interval/apperror"
+	imageSrv "github.com/nightx1x/ecommerce/interval/service/image"
+	policySrv "github.com/nightx1x/ecommerce/interval/service/policy"
+)
+
+// multipartOverhead allows for the multipart framing and form fields around
+// an upload of the maximum size
+const multipartOverhead = 1 << 20
+
+var errMissingFile = apperror.BadRequest("missing_file", "Multipart field \"file\" is required")
+
+type ImageHandler struct {
+	ImageSrv      imageSrv.ImageService
+	Guard         *Guard
+	MaxUploadSize int64
+}
+
+func NewImageHandler(srv imageSrv.ImageService, guard *Guard, maxUploadSize int64) *ImageHandler {
+	return &ImageHandler{ImageSrv: srv, Guard: guard, MaxUploadSize: maxUploadSize}
+}
+
+func (h *ImageHandler) RegisterRoutes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(h.Guard.Authenticate)
+		r.Use(h.Guard.Require(policySrv.PermAdminAccess))
+		r.Get("/admin/products/{id}/images", h.ListImages)
+		r.With(h.Guard.Require(policySrv.PermProductsWrite)).Post("/admin/products/{id}/images", h.UploadImage)
+		r.With(h.Guard.Require(policySrv.PermProductsWrite)).Patch("/admin/products/{id}/images/{imageID}", h.UpdateImage)
+		r.With(h.Guard.Require(policySrv.PermProductsWrite)).Delete("/admin/products/{id}/images/{imageID}", h.DeleteImage)
+	})
+}
+
+func (h *ImageHandler) ListImages(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, r, invalidID("product"))
+		return
+	}
+
+	images, err := h.ImageSrv.ListImages(r.Context(), id)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, images)
+}
+
+// UploadImage adds an image to the end of the product's gallery. The body
+// is multipart/form-data with the image in "file" and optional "alt_text".
+func (h *ImageHandler) UploadImage(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, r, invalidID("product"))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.MaxUploadSize+multipartOverhead)
+	if err := r.ParseMultipartForm(h.MaxUploadSize); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, r, imageSrv.ErrImageTooLarge)
+			return
+		}
+		respondError(w, r, errInvalidBody)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		respondError(w, r, errMissingFile)
+		return
+	}
+	defer file.Close()
+
+	image, err := h.ImageSrv.UploadImage(r.Context(), id, imageSrv.UploadImageRequest{
+		File:    file,
+		AltText: r.FormValue("alt_text"),
+	})
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, image)
+}
+
+// UpdateImage changes the alt text or the gallery position of an image
+func (h *ImageHandler) UpdateImage(w http.ResponseWriter, r *http.Request) {
+	id, imageID, ok := imagePath(w, r)
+	if !ok {
+		return
+	}
+
+	var req imageSrv.UpdateImageRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	image, err := h.ImageSrv.UpdateImage(r.Context(), id, imageID, req)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, image)
+}
+
+func (h *ImageHandler) DeleteImage(w http.ResponseWriter, r *http.Request) {
+	id, imageID, ok := imagePath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.ImageSrv.DeleteImage(r.Context(), id, imageID); err != nil {
+		respondError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// imagePath parses the product and image ids of an image route
+func imagePath(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, r, invalidID("product"))
+		return uuid.Nil, uuid.Nil, false
+	}
+	imageID, err := uuid.Parse(chi.URLParam(r, "imageID"))
+	if err != nil {
+		respondError(w, r, invalidID("image"))
+		return uuid.Nil, uuid.Nil, false
+	}
+	return id, imageID, true
+}
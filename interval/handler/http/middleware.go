@@ -0,0 +1,87 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	authSrv "github.com/nightx1x/ecommerce/interval/service/auth"
+	policySrv "github.com/nightx1x/ecommerce/interval/service/policy"
+)
+
+type contextKey string
+
+const (
+	userIDKey contextKey = "user_id"
+	roleKey   contextKey = "role"
+)
+
+// Guard bundles authentication and authorization middleware so that
+// handlers can declare per-route requirements in RegisterRoutes.
+type Guard struct {
+	AuthSrv   authSrv.AuthService
+	PolicySrv policySrv.PolicyService
+}
+
+func NewGuard(auth authSrv.AuthService, policy policySrv.PolicyService) *Guard {
+	return &Guard{AuthSrv: auth, PolicySrv: policy}
+}
+
+// Authenticate validates the Bearer access token and stores the user ID and
+// role in the request context.
+func (g *Guard) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || token == "" {
+			respondError(w, r, errMissingToken)
+			return
+		}
+
+		claims, err := g.AuthSrv.ParseAccessToken(token)
+		if err != nil {
+			respondError(w, r, err)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userIDKey, claims.UserID)
+		ctx = context.WithValue(ctx, roleKey, claims.Role)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Require only lets the request through if the authenticated user's current
+// role grants every listed permission. It must run after Authenticate.
+func (g *Guard) Require(perms ...policySrv.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := UserIDFromContext(r.Context())
+			if !ok {
+				respondError(w, r, errAuthRequired)
+				return
+			}
+
+			role, err := g.PolicySrv.Authorize(r.Context(), userID, perms...)
+			if err != nil {
+				respondError(w, r, err)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), roleKey, role)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// UserIDFromContext returns the authenticated user ID set by Authenticate
+func UserIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(userIDKey).(uuid.UUID)
+	return id, ok
+}
+
+// RoleFromContext returns the authenticated user role set by Authenticate
+func RoleFromContext(ctx context.Context) (string, bool) {
+	role, ok := ctx.Value(roleKey).(string)
+	return role, ok
+}
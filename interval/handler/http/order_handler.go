@@ -0,0 +1,192 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	models "github.com/nightx1x/ecommerce/interval/domain"
+	orderSrv "github.com/nightx1x/ecommerce/interval/service/order"
+	policySrv "github.com/nightx1x/ecommerce/interval/service/policy"
+)
+
+type OrderHandler struct {
+	OrderSrv orderSrv.OrderService
+	Guard    *Guard
+}
+
+func NewOrderHandler(srv orderSrv.OrderService, guard *Guard) *OrderHandler {
+	return &OrderHandler{OrderSrv: srv, Guard: guard}
+}
+
+func (h *OrderHandler) RegisterRoutes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(h.Guard.Authenticate)
+		r.Post("/orders", h.Checkout)
+		r.Get("/orders", h.ListOrders)
+		r.Get("/orders/{id}", h.GetOrder)
+		r.Get("/orders/{id}/history", h.GetOrderHistory)
+		r.Post("/orders/{id}/cancel", h.CancelOrder)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.Guard.Authenticate)
+		r.Use(h.Guard.Require(policySrv.PermAdminAccess))
+		r.With(h.Guard.Require(policySrv.PermOrdersManage)).Put("/admin/orders/{id}/status", h.UpdateOrderStatus)
+	})
+}
+
+func (h *OrderHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, errAuthRequired)
+		return
+	}
+
+	var req orderSrv.CreateOrderRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Currency == "" {
+		req.Currency = requestCurrency(r)
+	}
+
+	order, err := h.OrderSrv.CreateOrder(r.Context(), userID, req)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, order)
+}
+
+func (h *OrderHandler) ListOrders(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, errAuthRequired)
+		return
+	}
+
+	filter := models.OrderFilter{
+		UserID: &userID,
+		Limit:  20,
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			respondError(w, r, invalidParam("Invalid limit"))
+			return
+		}
+		filter.Limit = limit
+	}
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			respondError(w, r, invalidParam("Invalid offset"))
+			return
+		}
+		filter.Offset = offset
+	}
+
+	page, err := h.OrderSrv.ListOrder(r.Context(), &filter, r.URL.Query().Get("cursor"))
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	// Тіло лишається масивом для сумісності, курсори йдуть у Link
+	setCursorLinks(w, r, page.NextCursor, page.PrevCursor)
+	respondJSON(w, http.StatusOK, page.Orders)
+}
+
+func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	order, _, ok := h.loadOrder(w, r)
+	if !ok {
+		return
+	}
+	respondJSON(w, http.StatusOK, order)
+}
+
+func (h *OrderHandler) GetOrderHistory(w http.ResponseWriter, r *http.Request) {
+	order, _, ok := h.loadOrder(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := h.OrderSrv.GetStatusHistory(r.Context(), order.ID)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, history)
+}
+
+func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	order, userID, ok := h.loadOrder(w, r)
+	if !ok {
+		return
+	}
+
+	cancelled, err := h.OrderSrv.CancelOrder(r.Context(), order.ID, userID)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, cancelled)
+}
+
+// ADMIN PART
+func (h *OrderHandler) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, errAuthRequired)
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, r, invalidID("order"))
+		return
+	}
+
+	var req orderSrv.UpdateStatusRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	order, err := h.OrderSrv.UpdateOrderStatus(r.Context(), id, req.Status, userID)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, order)
+}
+
+// loadOrder reads the {id} order and checks that the caller owns it or holds
+// orders:manage. Orders of other users are reported as not found.
+func (h *OrderHandler) loadOrder(w http.ResponseWriter, r *http.Request) (*orderSrv.OrderResponse, uuid.UUID, bool) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, errAuthRequired)
+		return nil, uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, r, invalidID("order"))
+		return nil, uuid.Nil, false
+	}
+
+	order, err := h.OrderSrv.GetOrder(r.Context(), id)
+	if err != nil {
+		respondError(w, r, err)
+		return nil, uuid.Nil, false
+	}
+
+	if order.UserID != userID {
+		if _, err := h.Guard.PolicySrv.Authorize(r.Context(), userID, policySrv.PermOrdersManage); err != nil {
+			respondError(w, r, orderSrv.ErrOrderNotFound)
+			return nil, uuid.Nil, false
+		}
+	}
+	return order, userID, true
+}
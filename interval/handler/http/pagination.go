@@ -0,0 +1,69 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// setPageLinks sets an RFC 8288 Link header pointing to the first, previous,
+// next and last offset pages of the current request
+func setPageLinks(w http.ResponseWriter, r *http.Request, total, limit, offset int) {
+	if limit <= 0 {
+		return
+	}
+
+	links := []string{pageLink(r, "first", limit, 0)}
+	if offset > 0 {
+		prev := offset - limit
+		if prev < 0 {
+			prev = 0
+		}
+		links = append(links, pageLink(r, "prev", limit, prev))
+	}
+	if offset+limit < total {
+		links = append(links, pageLink(r, "next", limit, offset+limit))
+	}
+	last := 0
+	if total > 0 {
+		last = (total - 1) / limit * limit
+	}
+	links = append(links, pageLink(r, "last", limit, last))
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
+
+func pageLink(r *http.Request, rel string, limit, offset int) string {
+	u := *r.URL
+	q := u.Query()
+	q.Set("limit", strconv.Itoa(limit))
+	q.Set("offset", strconv.Itoa(offset))
+	q.Del("cursor")
+	u.RawQuery = q.Encode()
+	return fmt.Sprintf(`<%s>; rel="%s"`, u.RequestURI(), rel)
+}
+
+// setCursorLinks sets an RFC 8288 Link header pointing to the next and
+// previous keyset pages. Empty cursors are left out.
+func setCursorLinks(w http.ResponseWriter, r *http.Request, next, prev string) {
+	var links []string
+	if prev != "" {
+		links = append(links, cursorLink(r, "prev", prev))
+	}
+	if next != "" {
+		links = append(links, cursorLink(r, "next", next))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
+
+func cursorLink(r *http.Request, rel, cursor string) string {
+	u := *r.URL
+	q := u.Query()
+	q.Set("cursor", cursor)
+	q.Del("offset")
+	u.RawQuery = q.Encode()
+	return fmt.Sprintf(`<%s>; rel="%s"`, u.RequestURI(), rel)
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	policySrv "github.com/nightx1x/ecommerce/interval/service/policy"
+	pricingSrv "github.com/nightx1x/ecommerce/interval/service/pricing"
+)
+
+type PricingHandler struct {
+	PricingSrv pricingSrv.PricingService
+	Guard      *Guard
+}
+
+func NewPricingHandler(srv pricingSrv.PricingService, guard *Guard) *PricingHandler {
+	return &PricingHandler{PricingSrv: srv, Guard: guard}
+}
+
+func (h *PricingHandler) RegisterRoutes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Get("/exchange-rates", h.ListRates)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.Guard.Authenticate)
+		r.Use(h.Guard.Require(policySrv.PermAdminAccess))
+		r.Get("/admin/products/{id}/prices", h.ListProductPrices)
+		r.With(h.Guard.Require(policySrv.PermProductsWrite)).Post("/admin/exchange-rates", h.UploadRates)
+		r.With(h.Guard.Require(policySrv.PermProductsWrite)).Put("/admin/products/{id}/prices/{currency}", h.SetProductPrice)
+		r.With(h.Guard.Require(policySrv.PermProductsWrite)).Delete("/admin/products/{id}/prices/{currency}", h.DeleteProductPrice)
+	})
+}
+
+func (h *PricingHandler) ListRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := h.PricingSrv.ListRates(r.Context())
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, rates)
+}
+
+func (h *PricingHandler) UploadRates(w http.ResponseWriter, r *http.Request) {
+	var req pricingSrv.UploadRatesRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	rates, err := h.PricingSrv.UploadRates(r.Context(), req)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, rates)
+}
+
+func (h *PricingHandler) ListProductPrices(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, r, invalidID("product"))
+		return
+	}
+
+	prices, err := h.PricingSrv.ListProductPrices(r.Context(), id)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, prices)
+}
+
+func (h *PricingHandler) SetProductPrice(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, r, invalidID("product"))
+		return
+	}
+
+	var req pricingSrv.SetPriceRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	price, err := h.PricingSrv.SetProductPrice(r.Context(), id, chi.URLParam(r, "currency"), req)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, price)
+}
+
+func (h *PricingHandler) DeleteProductPrice(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, r, invalidID("product"))
+		return
+	}
+
+	if err := h.PricingSrv.DeleteProductPrice(r.Context(), id, chi.URLParam(r, "currency")); err != nil {
+		respondError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,33 +2,56 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
+	"mime"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/nightx1x/ecommerce/interval/apperror"
+	models "github.com/nightx1x/ecommerce/interval/domain"
+	policySrv "github.com/nightx1x/ecommerce/interval/service/policy"
+	pricingSrv "github.com/nightx1x/ecommerce/interval/service/pricing"
 	productSrv "github.com/nightx1x/ecommerce/interval/service/product"
+	"github.com/nightx1x/ecommerce/interval/validation"
 )
 
+const mergePatchContentType = "application/merge-patch+json"
+
 type ProductHandler struct {
 	ProductSrv productSrv.ProductService
+	PricingSrv pricingSrv.PricingService
+	Guard      *Guard
 }
 
-func NewProductHandler(srv productSrv.ProductService) *ProductHandler {
-	return &ProductHandler{ProductSrv: srv}
+func NewProductHandler(srv productSrv.ProductService, pricing pricingSrv.PricingService, guard *Guard) *ProductHandler {
+	return &ProductHandler{ProductSrv: srv, PricingSrv: pricing, Guard: guard}
 }
 
 func (h *ProductHandler) RegisterRoutes(r chi.Router) {
 	r.Group(func(r chi.Router) {
 		r.Get("/products", h.ListProducts)
 		r.Get("/products/search", h.SearchProduct)
+		r.Get("/products/suggest", h.SuggestProducts)
 		r.Get("/products/{id}", h.GetProduct)
-		r.Get("/categories", h.ListCategories)
 	})
 
 	r.Group(func(r chi.Router) {
-		r.Post("/admin/products", h.CreateProduct)
-		r.Put("/admin/products/{id}", h.UpdateProduct)
+		r.Use(h.Guard.Authenticate)
+		r.Use(h.Guard.Require(policySrv.PermAdminAccess))
+		r.Get("/admin/products", h.AdminListProducts)
+		r.Get("/admin/products/{id}", h.AdminGetProduct)
+		r.With(h.Guard.Require(policySrv.PermProductsWrite)).Post("/admin/products", h.CreateProduct)
+		r.With(h.Guard.Require(policySrv.PermProductsWrite)).Put("/admin/products/{id}", h.UpdateProduct)
+		r.With(h.Guard.Require(policySrv.PermProductsWrite)).Patch("/admin/products/{id}", h.PatchProduct)
+		r.With(h.Guard.Require(policySrv.PermProductsWrite)).Delete("/admin/products/{id}", h.ArchiveProduct)
+		r.With(h.Guard.Require(policySrv.PermProductsWrite)).Post("/admin/products/{id}/restore", h.RestoreProduct)
+		r.With(h.Guard.Require(policySrv.PermProductsWrite)).Post("/admin/products/{id}/variants", h.CreateVariant)
+		r.With(h.Guard.Require(policySrv.PermProductsWrite)).Put("/admin/products/{id}/variants/{variantID}", h.UpdateVariant)
+		r.With(h.Guard.Require(policySrv.PermProductsWrite)).Delete("/admin/products/{id}/variants/{variantID}", h.DeleteVariant)
 	})
 }
 
@@ -37,19 +60,124 @@ func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "InvalidID")
+		respondError(w, r, invalidID("product"))
+		return
+	}
+
+	quote, ok := h.quote(w, r)
+	if !ok {
 		return
 	}
 
 	product, err := h.ProductSrv.GetProductByID(r.Context(), id)
 	if err != nil {
-		handlerServiceError(w, err)
+		respondError(w, r, err)
+		return
+	}
+	if err := h.PricingSrv.PriceProducts(r.Context(), quote, product); err != nil {
+		respondError(w, r, err)
 		return
 	}
+	w.Header().Set("ETag", productETag(product))
+	varyCurrency(w)
 	respondJSON(w, http.StatusOK, product)
 }
 
+// ListProducts lists products with prices in the requested currency.
+// min_price and max_price are in that currency too, but are compared with
+// the converted default currency price, not explicit price lists.
 func (h *ProductHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
+	quote, ok := h.quote(w, r)
+	if !ok {
+		return
+	}
+	filter, ok := productFilterFromQuery(w, r, quote)
+	if !ok {
+		return
+	}
+
+	response, err := h.ProductSrv.ListProducts(r.Context(), filter)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	if err := h.PricingSrv.PriceProducts(r.Context(), quote, response.Products...); err != nil {
+		respondError(w, r, err)
+		return
+	}
+	if response.Facets != nil {
+		for _, b := range response.Facets.Price {
+			b.From, b.To = quote.FromBase(b.From), quote.FromBase(b.To)
+		}
+	}
+	setProductLinks(w, r, response)
+	varyCurrency(w)
+	respondJSON(w, http.StatusOK, response)
+}
+
+// quote resolves the currency of the request. ok is false if it is not
+// supported and an error response has already been written.
+func (h *ProductHandler) quote(w http.ResponseWriter, r *http.Request) (pricingSrv.Quote, bool) {
+	quote, err := h.PricingSrv.Quote(r.Context(), requestCurrency(r))
+	if err != nil {
+		respondError(w, r, err)
+		return quote, false
+	}
+	return quote, true
+}
+
+// setProductLinks sets the Link header for offset or cursor pages
+func setProductLinks(w http.ResponseWriter, r *http.Request, response *productSrv.ProductListResponse) {
+	if response.Pagination == nil {
+		setCursorLinks(w, r, response.NextCursor, response.PrevCursor)
+		return
+	}
+	setPageLinks(w, r, response.Total, response.Limit, response.Offset)
+}
+
+// attributeQueries collects the attribute filters of a query:
+// attr.<code>=a,b (repeatable) for any of the values and attr.<code>.min /
+// attr.<code>.max for number ranges
+func attributeQueries(query url.Values) map[string]productSrv.AttributeQuery {
+	var queries map[string]productSrv.AttributeQuery
+	for key, values := range query {
+		code, ok := strings.CutPrefix(key, "attr.")
+		if !ok || code == "" || len(values) == 0 {
+			continue
+		}
+		if queries == nil {
+			queries = map[string]productSrv.AttributeQuery{}
+		}
+		switch {
+		case strings.HasSuffix(code, ".min"):
+			code = strings.TrimSuffix(code, ".min")
+			q := queries[code]
+			q.Min = values[0]
+			queries[code] = q
+		case strings.HasSuffix(code, ".max"):
+			code = strings.TrimSuffix(code, ".max")
+			q := queries[code]
+			q.Max = values[0]
+			queries[code] = q
+		default:
+			q := queries[code]
+			for _, v := range values {
+				for _, part := range strings.Split(v, ",") {
+					if part = strings.TrimSpace(part); part != "" {
+						q.Values = append(q.Values, part)
+					}
+				}
+			}
+			queries[code] = q
+		}
+	}
+	return queries
+}
+
+// productFilterFromQuery parses the list query parameters. Prices are read
+// in the quote currency and converted to the default currency. ok is false
+// if one was invalid and an error response has already been written.
+func productFilterFromQuery(w http.ResponseWriter, r *http.Request, quote pricingSrv.Quote) (productSrv.ProductFilter, bool) {
 	filter := productSrv.ProductFilter{
 		Limit:  20,
 		Offset: 0,
@@ -58,33 +186,45 @@ func (h *ProductHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
 	if categoryIDStr := r.URL.Query().Get("category_id"); categoryIDStr != "" {
 		categoryID, err := uuid.Parse(categoryIDStr)
 		if err != nil {
-			respondError(w, http.StatusBadRequest, "Invalid category ID")
+			respondError(w, r, invalidID("category"))
+			return filter, false
 		}
 		filter.CategoryID = &categoryID
 	}
+	//includeSubcategories
+	filter.IncludeSubcategories = r.URL.Query().Get("include_subcategories") == "true"
 	//minPrice
 	if minPriceStr := r.URL.Query().Get("min_price"); minPriceStr != "" {
-		minPrice, err := strconv.ParseFloat(minPriceStr, 64)
+		minPrice, err := parsePrice(minPriceStr, quote)
 		if err != nil {
-			respondError(w, http.StatusBadRequest, "Invalid min_price")
+			respondError(w, r, invalidParam("Invalid min_price"))
+			return filter, false
 		}
-		filter.MinPrice = &minPrice
+		filter.MinPrice = minPrice
 	}
 	//maxPrice
 	if maxPriceStr := r.URL.Query().Get("max_price"); maxPriceStr != "" {
-		maxPrice, err := strconv.ParseFloat(maxPriceStr, 64)
+		maxPrice, err := parsePrice(maxPriceStr, quote)
 		if err != nil {
-			respondError(w, http.StatusBadRequest, "Invalid max_price")
+			respondError(w, r, invalidParam("Invalid max_price"))
+			return filter, false
 		}
-		filter.MaxPrice = &maxPrice
+		filter.MaxPrice = maxPrice
 	}
 
 	//Search
 	filter.Search = r.URL.Query().Get("search")
 
+	//Attributes
+	filter.Attributes = attributeQueries(r.URL.Query())
+
 	//InStock
 	if inStockStr := r.URL.Query().Get("in_stock"); inStockStr != "" {
-		instock := inStockStr == "true"
+		instock, err := strconv.ParseBool(inStockStr)
+		if err != nil {
+			respondError(w, r, invalidParam("Invalid in_stock"))
+			return filter, false
+		}
 		filter.InStock = &instock
 	}
 
@@ -92,7 +232,8 @@ func (h *ProductHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
 	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
 		limit, err := strconv.Atoi(limitStr)
 		if err != nil || limit <= 0 {
-			respondError(w, http.StatusBadRequest, "Invalid limit")
+			respondError(w, r, invalidParam("Invalid limit"))
+			return filter, false
 		}
 		filter.Limit = limit
 	}
@@ -101,66 +242,259 @@ func (h *ProductHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
 	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
 		offset, err := strconv.Atoi(offsetStr)
 		if err != nil || offset < 0 {
-			respondError(w, http.StatusBadRequest, "Invalid Offset")
+			respondError(w, r, invalidParam("Invalid offset"))
+			return filter, false
 		}
 		filter.Offset = offset
 	}
 
-	response, err := h.ProductSrv.ListProducts(r.Context(), filter)
-	if err != nil {
-		handlerServiceError(w, err)
+	//OrderBy
+	filter.OrderBy = r.URL.Query().Get("order_by")
+
+	//Cursor
+	filter.Cursor = r.URL.Query().Get("cursor")
+
+	if err := validation.Validate(filter); err != nil {
+		respondError(w, r, err)
+		return filter, false
 	}
-	respondJSON(w, http.StatusOK, response)
+	return filter, true
 }
 
-func (h *ProductHandler) ListCategories(w http.ResponseWriter, r *http.Request) {
-	categories := []interface{}{}
-	respondJSON(w, http.StatusOK, categories)
+// parsePrice reads a price filter in the quote currency and returns it in
+// the default currency
+func parsePrice(s string, quote pricingSrv.Quote) (*models.Money, error) {
+	currency := quote.Currency
+	if quote.IsBase() {
+		currency = models.DefaultCurrency
+	}
+	price, err := models.ParseMoney(s, currency)
+	if err != nil {
+		return nil, err
+	}
+	price = quote.ToBase(price)
+	return &price, nil
 }
 
+// SearchProduct runs a full text search for q. The list filters, such as
+// category_id and min_price, narrow the matches down. If nothing matches,
+// the results are for the closest product name given in did_you_mean.
 func (h *ProductHandler) SearchProduct(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query().Get("q")
-	if query == "" {
-		respondError(w, http.StatusBadRequest, "Search query is required")
+	quote, ok := h.quote(w, r)
+	if !ok {
+		return
+	}
+	filter, ok := productFilterFromQuery(w, r, quote)
+	if !ok {
+		return
+	}
+	filter.Search = r.URL.Query().Get("q")
+	if filter.Search == "" {
+		respondError(w, r, invalidParam("Search query is required"))
+		return
+	}
+
+	response, err := h.ProductSrv.SearchProducts(r.Context(), filter)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	products := make([]*models.Product, 0, len(response.Results))
+	for _, result := range response.Results {
+		products = append(products, &result.Product)
+	}
+	if err := h.PricingSrv.PriceProducts(r.Context(), quote, products...); err != nil {
+		respondError(w, r, err)
+		return
+	}
+	setPageLinks(w, r, response.Total, response.Limit, response.Offset)
+	varyCurrency(w)
+	respondJSON(w, http.StatusOK, response)
+}
+
+// SuggestProducts returns autocomplete entries for the partial query q
+func (h *ProductHandler) SuggestProducts(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("q")
+	if prefix == "" {
+		respondError(w, r, invalidParam("Search query is required"))
 		return
 	}
-	limit := 20
+	limit := 10
 	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
 		l, err := strconv.Atoi(limitStr)
-		if err != nil || l <= 0 {
-			respondError(w, http.StatusBadRequest, "Invalid limit")
+		if err != nil || l <= 0 || l > 20 {
+			respondError(w, r, invalidParam("Invalid limit"))
+			return
 		}
 		limit = l
 	}
 
-	offset := 0
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		o, err := strconv.Atoi(offsetStr)
-		if err != nil || o < 0 {
-			respondError(w, http.StatusBadRequest, "Invalid Offset")
-		}
-		offset = o
+	suggestions, err := h.ProductSrv.SuggestProducts(r.Context(), prefix, limit)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, suggestions)
+}
+
+// ADMIN PART
+
+// AdminListProducts lists products including archived ones, unless
+// include_archived=false is given
+func (h *ProductHandler) AdminListProducts(w http.ResponseWriter, r *http.Request) {
+	filter, ok := productFilterFromQuery(w, r, pricingSrv.Quote{Currency: models.DefaultCurrency})
+	if !ok {
+		return
 	}
+	filter.IncludeArchived = r.URL.Query().Get("include_archived") != "false"
 
-	products, err := h.ProductSrv.SearchProducts(r.Context(), query, limit, offset)
+	response, err := h.ProductSrv.ListProducts(r.Context(), filter)
 	if err != nil {
-		handlerServiceError(w, err)
+		respondError(w, r, err)
 		return
 	}
-	respondJSON(w, http.StatusOK, products)
+	setProductLinks(w, r, response)
+	respondJSON(w, http.StatusOK, response)
+}
+
+func (h *ProductHandler) AdminGetProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, r, invalidID("product"))
+		return
+	}
+
+	product, err := h.ProductSrv.GetProductIncludingArchived(r.Context(), id)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", productETag(product))
+	respondJSON(w, http.StatusOK, product)
+}
+
+// ArchiveProduct soft-deletes a product; it can be brought back with
+// RestoreProduct
+func (h *ProductHandler) ArchiveProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, r, invalidID("product"))
+		return
+	}
+
+	if _, err := h.ProductSrv.ArchiveProduct(r.Context(), id); err != nil {
+		respondError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ProductHandler) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, r, invalidID("product"))
+		return
+	}
+
+	product, err := h.ProductSrv.RestoreProduct(r.Context(), id)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", productETag(product))
+	respondJSON(w, http.StatusOK, product)
+}
+
+// CreateVariant adds a variant to a product. The first variant switches the
+// product to per-variant stock.
+func (h *ProductHandler) CreateVariant(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, r, invalidID("product"))
+		return
+	}
+
+	var req productSrv.VariantRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	variant, err := h.ProductSrv.CreateVariant(r.Context(), id, req)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", versionETag(variant.Version))
+	respondJSON(w, http.StatusCreated, variant)
+}
+
+// UpdateVariant replaces a variant. If-Match, or a version in the body,
+// guards against overwriting stock changed since the variant was read.
+func (h *ProductHandler) UpdateVariant(w http.ResponseWriter, r *http.Request) {
+	id, variantID, ok := variantPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req productSrv.VariantRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	version, conditional, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+	if conditional {
+		req.Version = version
+	}
+
+	variant, err := h.ProductSrv.UpdateVariant(r.Context(), id, variantID, req)
+	if err != nil {
+		respondConflict(w, r, err, conditional)
+		return
+	}
+	w.Header().Set("ETag", versionETag(variant.Version))
+	respondJSON(w, http.StatusOK, variant)
+}
+
+func (h *ProductHandler) DeleteVariant(w http.ResponseWriter, r *http.Request) {
+	id, variantID, ok := variantPath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.ProductSrv.DeleteVariant(r.Context(), id, variantID); err != nil {
+		respondError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// variantPath parses the product and variant ids of a variant route
+func variantPath(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, r, invalidID("product"))
+		return uuid.Nil, uuid.Nil, false
+	}
+	variantID, err := uuid.Parse(chi.URLParam(r, "variantID"))
+	if err != nil {
+		respondError(w, r, invalidID("variant"))
+		return uuid.Nil, uuid.Nil, false
+	}
+	return id, variantID, true
 }
 
-// ADMIN PART
 func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	var req productSrv.CreateProductRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	createProd, err := h.ProductSrv.CreateProduct(r.Context(), req)
 	if err != nil {
-		handlerServiceError(w, err)
+		respondError(w, r, err)
 		return
 	}
 
@@ -171,50 +505,143 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid product ID")
+		respondError(w, r, invalidID("product"))
 		return
 	}
 
 	var req productSrv.UpdateProductRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
+
+	// If-Match takes precedence over a version sent in the body
+	version, conditional, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+	if conditional {
+		req.Version = version
+	}
+
 	updProd, err := h.ProductSrv.UpdateProduct(r.Context(), id, req)
+	respondProductUpdate(w, r, updProd, err, conditional)
+}
+
+// PatchProduct applies a JSON Merge Patch (RFC 7386) to a product
+func (h *ProductHandler) PatchProduct(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
 	if err != nil {
-		handlerServiceError(w, err)
+		respondError(w, r, invalidID("product"))
+		return
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != mergePatchContentType {
+		w.Header().Set("Accept-Patch", mergePatchContentType)
+		respondError(w, r, apperror.New(http.StatusUnsupportedMediaType, "unsupported_media_type",
+			"Content-Type must be "+mergePatchContentType))
 		return
 	}
-	respondJSON(w, http.StatusOK, updProd)
+
+	var patch productSrv.ProductPatch
+	if !decodeJSON(w, r, &patch) {
+		return
+	}
+
+	version, conditional, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+	patch.Version = version
+
+	updProd, err := h.ProductSrv.PatchProduct(r.Context(), id, patch)
+	respondProductUpdate(w, r, updProd, err, conditional)
 }
 
-// utils
-func handlerServiceError(w http.ResponseWriter, err error) {
-	switch err {
-	case productSrv.ErrProductNotFound:
-		respondError(w, http.StatusNotFound, err.Error())
-	case productSrv.ErrProductNameRequired,
-		productSrv.ErrInvalidPrice,
-		productSrv.ErrInvalidStock,
-		productSrv.ErrInvalidQuantity:
-		respondError(w, http.StatusBadRequest, err.Error())
-	case productSrv.ErrInsufficientStock:
-		respondError(w, http.StatusConflict, err.Error())
-	default:
-		respondError(w, http.StatusInternalServerError, "Internal server error")
-	}
-}
-
-func respondError(w http.ResponseWriter, status int, msg string) {
-	respondJSON(w, status, ErrorResponse{
-		Error:  msg,
-		Status: status,
-	})
+// respondProductUpdate writes the result of an update
+func respondProductUpdate(w http.ResponseWriter, r *http.Request, product *models.Product, err error, conditional bool) {
+	if err != nil {
+		respondConflict(w, r, err, conditional)
+		return
+	}
+	w.Header().Set("ETag", productETag(product))
+	respondJSON(w, http.StatusOK, product)
+}
+
+// respondConflict writes a failed update. A version conflict is 412 for
+// requests sent with If-Match and 409 otherwise.
+func respondConflict(w http.ResponseWriter, r *http.Request, err error, conditional bool) {
+	var conflictErr *productSrv.VersionConflictError
+	if conditional && errors.As(err, &conflictErr) {
+		respondError(w, r, preconditionFailed(conflictErr.Error()).Wrap(err))
+		return
+	}
+	respondError(w, r, err)
+}
+
+// ifMatchVersion reads the If-Match header of r. present reports whether
+// the header was sent; ok is false if it was invalid and a response has
+// already been written.
+func ifMatchVersion(w http.ResponseWriter, r *http.Request) (version *int, present bool, ok bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return nil, false, true
+	}
+	// If-Match порівнює теги строго (RFC 7232), слабкий тег не збігається ніколи
+	if strings.HasPrefix(strings.TrimSpace(header), "W/") {
+		respondError(w, r, preconditionFailed("If-Match requires a strong entity tag"))
+		return nil, true, false
+	}
+	version, ok = parseIfMatch(header)
+	if !ok {
+		respondError(w, r, invalidParam("Invalid If-Match header"))
+		return nil, true, false
+	}
+	return version, true, true
+}
+
+// productETag returns the strong entity tag of a product, its quoted version
+func productETag(product *models.Product) string {
+	return versionETag(product.Version)
 }
 
-type ErrorResponse struct {
-	Error  string `json:"error"`
-	Status int    `json:"status"`
+// versionETag quotes a row version as a strong entity tag
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseIfMatch reads the version from an If-Match header with a strong
+// entity tag. "*" matches any version and yields nil.
+func parseIfMatch(header string) (*int, bool) {
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return nil, true
+	}
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return nil, false
+	}
+	version, err := strconv.Atoi(header[1 : len(header)-1])
+	if err != nil || version < 1 {
+		return nil, false
+	}
+	return &version, true
+}
+
+// utils
+
+// decodeJSON reads the request body into dst and validates it. ok is false
+// if either failed and an error response has already been written.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		respondError(w, r, errInvalidBody)
+		return false
+	}
+	if err := validation.Validate(dst); err != nil {
+		respondError(w, r, err)
+		return false
+	}
+	return true
 }
 
 func respondJSON(w http.ResponseWriter, status int, data interface{}) {
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	userSrv "github.com/nightx1x/ecommerce/interval/service/user"
+)
+
+type UserHandler struct {
+	UserSrv userSrv.UserService
+	Guard   *Guard
+}
+
+func NewUserHandler(srv userSrv.UserService, guard *Guard) *UserHandler {
+	return &UserHandler{UserSrv: srv, Guard: guard}
+}
+
+func (h *UserHandler) RegisterRoutes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(h.Guard.Authenticate)
+		r.Get("/users/me", h.GetMe)
+		r.Patch("/users/me", h.UpdateMe)
+		r.Put("/users/me/password", h.ChangePassword)
+		r.Delete("/users/me", h.DeleteMe)
+	})
+}
+
+func (h *UserHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, errAuthRequired)
+		return
+	}
+
+	user, err := h.UserSrv.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, user)
+}
+
+func (h *UserHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, errAuthRequired)
+		return
+	}
+
+	var req userSrv.UpdateProfileRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, err := h.UserSrv.UpdateProfile(r.Context(), userID, req)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, user)
+}
+
+func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, errAuthRequired)
+		return
+	}
+
+	var req userSrv.ChangePasswordRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.UserSrv.ChangePassword(r.Context(), userID, req); err != nil {
+		respondError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserHandler) DeleteMe(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, errAuthRequired)
+		return
+	}
+
+	if err := h.UserSrv.DeleteUser(r.Context(), userID); err != nil {
+		respondError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	database "github.com/nightx1x/ecommerce/interval/db"
+	models "github.com/nightx1x/ecommerce/interval/domain"
+)
+
+type AttributeRepository interface {
+	List(ctx context.Context, filterableOnly bool) ([]*models.Attribute, error)
+	ListByCodes(ctx context.Context, codes []string) ([]*models.Attribute, error)
+	Create(ctx context.Context, attribute *models.Attribute) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	ListByProduct(ctx context.Context, productID uuid.UUID) ([]*models.ProductAttribute, error)
+	SetProductValues(ctx context.Context, productID uuid.UUID, values []*models.ProductAttribute) error
+}
+
+type attributeRepo struct {
+	db *database.DB
+}
+
+// List implements AttributeRepository.
+func (a *attributeRepo) List(ctx context.Context, filterableOnly bool) ([]*models.Attribute, error) {
+	query := `
+		SELECT id, code, name, type, unit, filterable, created_at
+		FROM attributes
+		WHERE filterable OR NOT $1
+		ORDER BY name, code
+	`
+	attributes := []*models.Attribute{}
+	if err := a.db.SelectContext(ctx, &attributes, query, filterableOnly); err != nil {
+		return nil, fmt.Errorf("failed to list attributes: %w", err)
+	}
+	return attributes, nil
+}
+
+// ListByCodes implements AttributeRepository. Unknown codes are skipped.
+func (a *attributeRepo) ListByCodes(ctx context.Context, codes []string) ([]*models.Attribute, error) {
+	query := `
+		SELECT id, code, name, type, unit, filterable, created_at
+		FROM attributes
+		WHERE code = ANY($1)
+		ORDER BY code
+	`
+	attributes := []*models.Attribute{}
+	if err := a.db.SelectContext(ctx, &attributes, query, pq.Array(codes)); err != nil {
+		return nil, fmt.Errorf("failed to list attributes: %w", err)
+	}
+	return attributes, nil
+}
+
+// Create implements AttributeRepository.
+func (a *attributeRepo) Create(ctx context.Context, attribute *models.Attribute) error {
+	query := `
+		INSERT INTO attributes (id, code, name, type, unit, filterable)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at
+	`
+	err := a.db.QueryRowContext(ctx, query,
+		attribute.ID,
+		attribute.Code,
+		attribute.Name,
+		attribute.Type,
+		attribute.Unit,
+		attribute.Filterable,
+	).Scan(&attribute.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create attribute: %w", err)
+	}
+	return nil
+}
+
+// Delete implements AttributeRepository. The values of the attribute are
+// deleted with it.
+func (a *attributeRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	res, err := a.db.ExecContext(ctx, `DELETE FROM attributes WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete attribute: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete attribute: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("failed to delete attribute: %w", ErrNotFound)
+	}
+	return nil
+}
+
+// ListByProduct implements AttributeRepository.
+func (a *attributeRepo) ListByProduct(ctx context.Context, productID uuid.UUID) ([]*models.ProductAttribute, error) {
+	query := `
+		SELECT pav.product_id, pav.attribute_id, a.code, a.name, a.type, a.unit,
+			pav.value_text, pav.value_number, pav.value_bool
+		FROM product_attribute_values pav
+		JOIN attributes a ON a.id = pav.attribute_id
+		WHERE pav.product_id = $1
+		ORDER BY a.name, a.code
+	`
+	values := []*models.ProductAttribute{}
+	if err := a.db.SelectContext(ctx, &values, query, productID); err != nil {
+		return nil, fmt.Errorf("failed to list product attributes: %w", err)
+	}
+	return values, nil
+}
+
+// SetProductValues implements AttributeRepository. The product's values
+// are replaced by values in one transaction.
+func (a *attributeRepo) SetProductValues(ctx context.Context, productID uuid.UUID, values []*models.ProductAttribute) error {
+	tx, err := a.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM product_attribute_values WHERE product_id = $1`, productID); err != nil {
+		return fmt.Errorf("failed to clear product attributes: %w", err)
+	}
+
+	query := `
+		INSERT INTO product_attribute_values (product_id, attribute_id, value_text, value_number, value_bool)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	for _, v := range values {
+		v.ProductID = productID
+		_, err := tx.ExecContext(ctx, query, productID, v.AttributeID, v.Text, v.Number, v.Bool)
+		if err != nil {
+			return fmt.Errorf("failed to set product attribute: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit product attributes: %w", err)
+	}
+	return nil
+}
+
+func NewAttributeRepository(db *database.DB) AttributeRepository {
+	return &attributeRepo{db: db}
+}
@@ -10,84 +10,170 @@ import (
 )
 
 type CartRepository interface {
-	CreateItem(ctx context.Context, cart *models.Cart) error
-	GetByID(ctx context.Context, id uuid.UUID) (*models.Cart, error)
-	UpdateItem(ctx context.Context, cart *models.Cart) error
-	DeleteItem(ctx context.Context, id uuid.UUID) error
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.CartItem, error)
+	GetItem(ctx context.Context, userID, productID uuid.UUID, variantID *uuid.UUID) (*models.CartItem, error)
+	AddItem(ctx context.Context, item *models.CartItem) error
+	SubtractItem(ctx context.Context, userID, productID uuid.UUID, variantID *uuid.UUID, quantity int) error
+	SetQuantity(ctx context.Context, item *models.CartItem) error
+	RemoveItem(ctx context.Context, userID, productID uuid.UUID, variantID *uuid.UUID) (bool, error)
+	Clear(ctx context.Context, userID uuid.UUID) error
 }
+
+// cartItemKey is the conflict target of idx_cart_items_user_product_variant:
+// a product without a variant is one row per user, like each variant
+const cartItemKey = `(user_id, product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'))`
+
 type cartRepo struct {
 	db *database.DB
 }
 
-// Create implements CartRepository.
-func (c *cartRepo) CreateItem(ctx context.Context, cart *models.Cart) error {
+// ListByUser implements CartRepository.
+func (c *cartRepo) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.CartItem, error) {
 	query := `
-		INSERT INTO carts (id, user_id, items, created_at, updated_at)
+		SELECT id, user_id, product_id, variant_id, quantity, created_at
+		FROM cart_items
+		WHERE user_id = $1
+		ORDER BY created_at, id
+	`
+	items := []*models.CartItem{}
+	err := c.db.SelectContext(ctx, &items, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cart items: %w", err)
+	}
+	return items, nil
+}
+
+// GetItem implements CartRepository.
+func (c *cartRepo) GetItem(ctx context.Context, userID, productID uuid.UUID, variantID *uuid.UUID) (*models.CartItem, error) {
+	query := `
+		SELECT id, user_id, product_id, variant_id, quantity, created_at
+		FROM cart_items
+		WHERE user_id = $1 AND product_id = $2 AND variant_id IS NOT DISTINCT FROM $3
+	`
+	var item models.CartItem
+	err := c.db.GetContext(ctx, &item, query, userID, productID, variantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart item: %w", notFound(err))
+	}
+	return &item, nil
+}
+
+// AddItem implements CartRepository. If the product (or variant) is already
+// in the cart the quantities are summed.
+func (c *cartRepo) AddItem(ctx context.Context, item *models.CartItem) error {
+	query := `
+		INSERT INTO cart_items (id, user_id, product_id, variant_id, quantity)
 		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT ` + cartItemKey + `
+		DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity
+		RETURNING id, quantity, created_at
 	`
-	_, err := c.db.ExecContext(ctx, query,
-		cart.ID,
-		cart.UserID,
-		cart.Items,
-		cart.CreatedAt,
-		cart.UpdatedAt,
-	)
+	err := c.db.QueryRowContext(ctx, query,
+		item.ID,
+		item.UserID,
+		item.ProductID,
+		item.VariantID,
+		item.Quantity,
+	).Scan(&item.ID, &item.Quantity, &item.CreatedAt)
 	if err != nil {
-		return fmt.Errorf("failed to create cart: %w", err)
+		return fmt.Errorf("failed to add cart item: %w", err)
 	}
 	return nil
 }
 
-// Delete implements CartRepository.
-func (c *cartRepo) DeleteItem(ctx context.Context, id uuid.UUID) error {
+// SubtractItem implements CartRepository. It takes quantity off the line
+// and deletes the line if nothing would be left.
+func (c *cartRepo) SubtractItem(ctx context.Context, userID, productID uuid.UUID, variantID *uuid.UUID, quantity int) error {
+	tx, err := c.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current int
 	query := `
-		DELETE FROM carts
-		WHERE id = $1
+		SELECT quantity
+		FROM cart_items
+		WHERE user_id = $1 AND product_id = $2 AND variant_id IS NOT DISTINCT FROM $3
+		FOR UPDATE
 	`
-	_, err := c.db.ExecContext(ctx, query, id)
+	err = tx.GetContext(ctx, &current, query, userID, productID, variantID)
 	if err != nil {
-		return fmt.Errorf("failed to delete cart: %w", err)
+		return fmt.Errorf("failed to get cart item: %w", notFound(err))
+	}
+
+	if current > quantity {
+		query = `
+			UPDATE cart_items
+			SET quantity = quantity - $4
+			WHERE user_id = $1 AND product_id = $2 AND variant_id IS NOT DISTINCT FROM $3
+		`
+		_, err = tx.ExecContext(ctx, query, userID, productID, variantID, quantity)
+	} else {
+		query = `
+			DELETE FROM cart_items
+			WHERE user_id = $1 AND product_id = $2 AND variant_id IS NOT DISTINCT FROM $3
+		`
+		_, err = tx.ExecContext(ctx, query, userID, productID, variantID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to subtract cart item: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit cart item: %w", err)
+	}
+	return nil
+}
+
+// SetQuantity implements CartRepository. The row is created if missing.
+func (c *cartRepo) SetQuantity(ctx context.Context, item *models.CartItem) error {
+	query := `
+		INSERT INTO cart_items (id, user_id, product_id, variant_id, quantity)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT ` + cartItemKey + `
+		DO UPDATE SET quantity = EXCLUDED.quantity
+		RETURNING id, quantity, created_at
+	`
+	err := c.db.QueryRowContext(ctx, query,
+		item.ID,
+		item.UserID,
+		item.ProductID,
+		item.VariantID,
+		item.Quantity,
+	).Scan(&item.ID, &item.Quantity, &item.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to set cart item quantity: %w", err)
 	}
 	return nil
 }
 
-// GetByID implements CartRepository.
-func (c *cartRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Cart, error) {
+// RemoveItem implements CartRepository. It reports whether a row was deleted.
+func (c *cartRepo) RemoveItem(ctx context.Context, userID, productID uuid.UUID, variantID *uuid.UUID) (bool, error) {
 	query := `
-		SELECT id, user_id, items, created_at, updated_at
-		FROM carts
-		WHERE id = $1
+		DELETE FROM cart_items
+		WHERE user_id = $1 AND product_id = $2 AND variant_id IS NOT DISTINCT FROM $3
 	`
-	row := c.db.QueryRowContext(ctx, query, id)
-	cart := &models.Cart{}
-	err := row.Scan(
-		&cart.ID,
-		&cart.UserID,
-		&cart.Items,
-		&cart.CreatedAt,
-		&cart.UpdatedAt,
-	)
+	res, err := c.db.ExecContext(ctx, query, userID, productID, variantID)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove cart item: %w", err)
+	}
+	n, err := res.RowsAffected()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get cart: %w", err)
+		return false, fmt.Errorf("failed to remove cart item: %w", err)
 	}
-	return cart, nil
+	return n > 0, nil
 }
 
-// Update implements CartRepository.
-func (c *cartRepo) UpdateItem(ctx context.Context, cart *models.Cart) error {
+// Clear implements CartRepository.
+func (c *cartRepo) Clear(ctx context.Context, userID uuid.UUID) error {
 	query := `
-		UPDATE carts
-		SET user_id = $1, items = $2, updated_at = $3
-		WHERE id = $4
+		DELETE FROM cart_items
+		WHERE user_id = $1
 	`
-	_, err := c.db.ExecContext(ctx, query,
-		cart.UserID,
-		cart.Items,
-		cart.UpdatedAt,
-		cart.ID,
-	)
+	_, err := c.db.ExecContext(ctx, query, userID)
 	if err != nil {
-		return fmt.Errorf("failed to update cart: %w", err)
+		return fmt.Errorf("failed to clear cart: %w", err)
 	}
 	return nil
 }
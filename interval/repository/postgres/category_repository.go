@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	database "github.com/nightx1x/ecommerce/interval/db"
+	models "github.com/nightx1x/ecommerce/interval/domain"
+)
+
+type CategoryRepository interface {
+	Create(ctx context.Context, category *models.Category) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Category, error)
+	List(ctx context.Context) ([]*models.CategoryWithCount, error)
+	Update(ctx context.Context, category *models.Category) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	Exists(ctx context.Context, id uuid.UUID) (bool, error)
+	Ancestors(ctx context.Context, id uuid.UUID) ([]*models.Category, error)
+}
+
+type categoryRepo struct {
+	db *database.DB
+}
+
+// Create implements CategoryRepository.
+func (c *categoryRepo) Create(ctx context.Context, category *models.Category) error {
+	query := `
+		INSERT INTO categories (id, name, description, parent_id)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at
+	`
+	err := c.db.QueryRowContext(ctx, query,
+		category.ID,
+		category.Name,
+		category.Description,
+		category.ParentID,
+	).Scan(&category.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create category: %w", err)
+	}
+	return nil
+}
+
+// GetByID implements CategoryRepository.
+func (c *categoryRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Category, error) {
+	query := `
+		SELECT id, name, description, parent_id, created_at
+		FROM categories
+		WHERE id = $1
+	`
+	var category models.Category
+	err := c.db.GetContext(ctx, &category, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category: %w", notFound(err))
+	}
+	return &category, nil
+}
+
+// List implements CategoryRepository. Archived products are not counted.
+func (c *categoryRepo) List(ctx context.Context) ([]*models.CategoryWithCount, error) {
+	query := `
+		SELECT c.id, c.name, c.description, c.parent_id, c.created_at, COUNT(p.id) AS product_count
+		FROM categories c
+		LEFT JOIN products p ON p.category_id = c.id AND p.deleted_at IS NULL
+		GROUP BY c.id
+		ORDER BY c.name
+	`
+	categories := []*models.CategoryWithCount{}
+	err := c.db.SelectContext(ctx, &categories, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	return categories, nil
+}
+
+// Update implements CategoryRepository.
+func (c *categoryRepo) Update(ctx context.Context, category *models.Category) error {
+	query := `
+		UPDATE categories
+		SET name = $1, description = $2, parent_id = $3
+		WHERE id = $4
+	`
+	_, err := c.db.ExecContext(ctx, query,
+		category.Name,
+		category.Description,
+		category.ParentID,
+		category.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update category: %w", err)
+	}
+	return nil
+}
+
+// Delete implements CategoryRepository.
+func (c *categoryRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `
+		DELETE FROM categories
+		WHERE id = $1
+	`
+	_, err := c.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+	return nil
+}
+
+// Exists implements CategoryRepository.
+func (c *categoryRepo) Exists(ctx context.Context, id uuid.UUID) (bool, error) {
+	var exists bool
+	err := c.db.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM categories WHERE id = $1)`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to check category: %w", err)
+	}
+	return exists, nil
+}
+
+// Ancestors implements CategoryRepository. It returns the path from the root
+// down to and including the category itself.
+func (c *categoryRepo) Ancestors(ctx context.Context, id uuid.UUID) ([]*models.Category, error) {
+	query := `
+		WITH RECURSIVE path AS (
+			SELECT id, name, description, parent_id, created_at, 0 AS depth
+			FROM categories
+			WHERE id = $1
+			UNION ALL
+			SELECT c.id, c.name, c.description, c.parent_id, c.created_at, path.depth + 1
+			FROM categories c
+			JOIN path ON c.id = path.parent_id
+			WHERE path.depth < 100
+		)
+		SELECT id, name, description, parent_id, created_at
+		FROM path
+		ORDER BY depth DESC
+	`
+	categories := []*models.Category{}
+	err := c.db.SelectContext(ctx, &categories, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category ancestors: %w", err)
+	}
+	return categories, nil
+}
+
+func NewCategoryRepository(db *database.DB) CategoryRepository {
+	return &categoryRepo{db: db}
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// Errors returned by repositories when a write violates a business rule
+// that can only be checked inside the transaction.
+var (
+	ErrInsufficientStock  = errors.New("insufficient stock")
+	ErrEmptyCart          = errors.New("cart is empty")
+	ErrStatusChanged      = errors.New("order status changed concurrently")
+	ErrVersionConflict    = errors.New("row version changed concurrently")
+	ErrProductUnavailable = errors.New("product is no longer available")
+	ErrProductHeld        = errors.New("product has active holds without a variant")
+)
+
+// ErrNotFound is returned when the requested row does not exist. Callers
+// above the repository layer check for it instead of sql.ErrNoRows.
+var ErrNotFound = errors.New("record not found")
+
+// notFound translates sql.ErrNoRows into ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// isUniqueViolation reports whether err is a PostgreSQL unique_violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// flipDirection reverses an ORDER BY direction for backward keyset pages
+func flipDirection(dir string) string {
+	if dir == "ASC" {
+		return "DESC"
+	}
+	return "ASC"
+}
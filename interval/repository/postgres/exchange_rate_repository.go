@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	database "github.com/nightx1x/ecommerce/interval/db"
+	models "github.com/nightx1x/ecommerce/interval/domain"
+)
+
+type ExchangeRateRepository interface {
+	CreateMany(ctx context.Context, rates []*models.ExchangeRate) error
+	Current(ctx context.Context, currency string, at time.Time) (*models.ExchangeRate, error)
+	ListCurrent(ctx context.Context, at time.Time) ([]*models.ExchangeRate, error)
+}
+
+type exchangeRateRepo struct {
+	db *database.DB
+}
+
+// CreateMany implements ExchangeRateRepository. The rates are stored in one
+// transaction; a rate for the same currency and effective_from replaces
+// the earlier upload.
+func (e *exchangeRateRepo) CreateMany(ctx context.Context, rates []*models.ExchangeRate) error {
+	tx, err := e.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO exchange_rates (id, currency, rate, effective_from)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (currency, effective_from)
+		DO UPDATE SET rate = EXCLUDED.rate
+		RETURNING id, created_at
+	`
+	for _, rate := range rates {
+		err := tx.QueryRowContext(ctx, query,
+			rate.ID,
+			rate.Currency,
+			rate.Rate,
+			rate.EffectiveFrom,
+		).Scan(&rate.ID, &rate.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create exchange rate: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit exchange rates: %w", err)
+	}
+	return nil
+}
+
+// Current implements ExchangeRateRepository. It returns the latest rate of
+// currency that is effective at the given time.
+func (e *exchangeRateRepo) Current(ctx context.Context, currency string, at time.Time) (*models.ExchangeRate, error) {
+	query := `
+		SELECT id, currency, rate, effective_from, created_at
+		FROM exchange_rates
+		WHERE currency = $1 AND effective_from <= $2
+		ORDER BY effective_from DESC
+		LIMIT 1
+	`
+	var rate models.ExchangeRate
+	if err := e.db.GetContext(ctx, &rate, query, currency, at); err != nil {
+		return nil, fmt.Errorf("failed to get exchange rate: %w", notFound(err))
+	}
+	return &rate, nil
+}
+
+// ListCurrent implements ExchangeRateRepository.
+func (e *exchangeRateRepo) ListCurrent(ctx context.Context, at time.Time) ([]*models.ExchangeRate, error) {
+	query := `
+		SELECT DISTINCT ON (currency) id, currency, rate, effective_from, created_at
+		FROM exchange_rates
+		WHERE effective_from <= $1
+		ORDER BY currency, effective_from DESC
+	`
+	rates := []*models.ExchangeRate{}
+	if err := e.db.SelectContext(ctx, &rates, query, at); err != nil {
+		return nil, fmt.Errorf("failed to list exchange rates: %w", err)
+	}
+	return rates, nil
+}
+
+func NewExchangeRateRepository(db *database.DB) ExchangeRateRepository {
+	return &exchangeRateRepo{db: db}
+}
@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	database "github.com/nightx1x/ecommerce/interval/db"
+	models "github.com/nightx1x/ecommerce/interval/domain"
+)
+
+type ImageRepository interface {
+	ListByProduct(ctx context.Context, productID uuid.UUID) ([]*models.ProductImage, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.ProductImage, error)
+	Create(ctx context.Context, image *models.ProductImage) error
+	UpdateAltText(ctx context.Context, image *models.ProductImage) error
+	Move(ctx context.Context, image *models.ProductImage, position int) error
+	Delete(ctx context.Context, image *models.ProductImage) error
+}
+
+type imageRepo struct {
+	db *database.DB
+}
+
+// ListByProduct implements ImageRepository.
+func (i *imageRepo) ListByProduct(ctx context.Context, productID uuid.UUID) ([]*models.ProductImage, error) {
+	images := []*models.ProductImage{}
+	if err := i.db.SelectContext(ctx, &images, selectImages+` WHERE product_id = $1 ORDER BY position`, productID); err != nil {
+		return nil, fmt.Errorf("failed to list product images: %w", err)
+	}
+	return images, nil
+}
+
+// GetByID implements ImageRepository.
+func (i *imageRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.ProductImage, error) {
+	var image models.ProductImage
+	if err := i.db.GetContext(ctx, &image, selectImages+` WHERE id = $1`, id); err != nil {
+		return nil, fmt.Errorf("failed to get product image: %w", notFound(err))
+	}
+	return &image, nil
+}
+
+// Create implements ImageRepository. The image is appended to the end of
+// the gallery; its Position is set from the database.
+func (i *imageRepo) Create(ctx context.Context, image *models.ProductImage) error {
+	tx, err := i.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockProduct(ctx, tx, image.ProductID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO product_images (id, product_id, storage_key, url, content_type, size_bytes, width, height, alt_text, position, thumbnails)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9,
+			(SELECT COALESCE(MAX(position) + 1, 0) FROM product_images WHERE product_id = $2), $10)
+		RETURNING position, created_at
+	`
+	err = tx.QueryRowContext(ctx, query,
+		image.ID,
+		image.ProductID,
+		image.StorageKey,
+		image.URL,
+		image.ContentType,
+		image.Size,
+		image.Width,
+		image.Height,
+		image.AltText,
+		image.Thumbnails,
+	).Scan(&image.Position, &image.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create product image: %w", err)
+	}
+
+	if err := syncPrimaryImage(ctx, tx, image.ProductID, ""); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit product image: %w", err)
+	}
+	return nil
+}
+
+// UpdateAltText implements ImageRepository.
+func (i *imageRepo) UpdateAltText(ctx context.Context, image *models.ProductImage) error {
+	res, err := i.db.ExecContext(ctx, `UPDATE product_images SET alt_text = $1 WHERE id = $2`, image.AltText, image.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update product image: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update product image: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("failed to update product image: %w", ErrNotFound)
+	}
+	return nil
+}
+
+// Move implements ImageRepository. The gallery is renumbered from 0 with
+// image at position, clamped to the last slot.
+func (i *imageRepo) Move(ctx context.Context, image *models.ProductImage, position int) error {
+	tx, err := i.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockProduct(ctx, tx, image.ProductID); err != nil {
+		return err
+	}
+
+	var ids []uuid.UUID
+	err = tx.SelectContext(ctx, &ids, `SELECT id FROM product_images WHERE product_id = $1 ORDER BY position`, image.ProductID)
+	if err != nil {
+		return fmt.Errorf("failed to list product images: %w", err)
+	}
+
+	order := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if id != image.ID {
+			order = append(order, id)
+		}
+	}
+	if len(order) == len(ids) {
+		return fmt.Errorf("failed to move product image: %w", ErrNotFound)
+	}
+	if position > len(order) {
+		position = len(order)
+	}
+	order = append(order[:position], append([]uuid.UUID{image.ID}, order[position:]...)...)
+
+	if err := renumberImages(ctx, tx, order); err != nil {
+		return err
+	}
+	if err := syncPrimaryImage(ctx, tx, image.ProductID, ""); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit product images: %w", err)
+	}
+	image.Position = position
+	return nil
+}
+
+// Delete implements ImageRepository. The remaining images close the gap;
+// products.image_url moves to the new first image, or is cleared when it
+// pointed at the deleted one.
+func (i *imageRepo) Delete(ctx context.Context, image *models.ProductImage) error {
+	tx, err := i.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockProduct(ctx, tx, image.ProductID); err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM product_images WHERE id = $1`, image.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete product image: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete product image: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("failed to delete product image: %w", ErrNotFound)
+	}
+
+	var ids []uuid.UUID
+	err = tx.SelectContext(ctx, &ids, `SELECT id FROM product_images WHERE product_id = $1 ORDER BY position`, image.ProductID)
+	if err != nil {
+		return fmt.Errorf("failed to list product images: %w", err)
+	}
+	if err := renumberImages(ctx, tx, ids); err != nil {
+		return err
+	}
+	if err := syncPrimaryImage(ctx, tx, image.ProductID, image.URL); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit product image: %w", err)
+	}
+	return nil
+}
+
+const selectImages = `
+	SELECT id, product_id, storage_key, url, content_type, size_bytes, width, height, alt_text, position, thumbnails, created_at
+	FROM product_images`
+
+// lockProduct serializes gallery changes of one product
+func lockProduct(ctx context.Context, tx *sqlx.Tx, productID uuid.UUID) error {
+	var id uuid.UUID
+	if err := tx.GetContext(ctx, &id, `SELECT id FROM products WHERE id = $1 FOR UPDATE`, productID); err != nil {
+		return fmt.Errorf("failed to lock product: %w", notFound(err))
+	}
+	return nil
+}
+
+// renumberImages sets the positions of ids to their index
+func renumberImages(ctx context.Context, tx *sqlx.Tx, ids []uuid.UUID) error {
+	for position, id := range ids {
+		if _, err := tx.ExecContext(ctx, `UPDATE product_images SET position = $1 WHERE id = $2`, position, id); err != nil {
+			return fmt.Errorf("failed to reorder product images: %w", err)
+		}
+	}
+	return nil
+}
+
+// syncPrimaryImage mirrors the first gallery image into products.image_url
+// and bumps the version, so cached product responses see the new gallery.
+// Without images, an image_url equal to removedURL is cleared and any other
+// one is kept.
+func syncPrimaryImage(ctx context.Context, tx *sqlx.Tx, productID uuid.UUID, removedURL string) error {
+	query := `
+		UPDATE products
+		SET image_url = COALESCE(
+				(SELECT url FROM product_images WHERE product_id = $1 ORDER BY position LIMIT 1),
+				NULLIF(image_url, $2)
+			),
+			version = version + 1, updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := tx.ExecContext(ctx, query, productID, removedURL); err != nil {
+		return fmt.Errorf("failed to update product image url: %w", err)
+	}
+	return nil
+}
+
+func NewImageRepository(db *database.DB) ImageRepository {
+	return &imageRepo{db: db}
+}
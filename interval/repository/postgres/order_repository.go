@@ -3,127 +3,401 @@ package repository
 import (
 	"context"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	database "github.com/nightx1x/ecommerce/interval/db"
 	models "github.com/nightx1x/ecommerce/interval/domain"
 )
 
 type OrderRepository interface {
 	Create(ctx context.Context, order *models.Order) error
+	CreateFromCart(ctx context.Context, order *models.Order) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Order, error)
 	List(ctx context.Context, filter *models.OrderFilter) ([]*models.Order, error)
-	UpdateStatus(ctx context.Context, id uuid.UUID, status string) error
-	Cancel(ctx context.Context, id uuid.UUID) error
+	UpdateStatus(ctx context.Context, id uuid.UUID, from, to string, changedBy uuid.UUID) error
+	ListStatusHistory(ctx context.Context, orderID uuid.UUID) ([]*models.OrderStatusChange, error)
 }
 type orderRepo struct {
 	db *database.DB
 }
 
-// Create implements OrderRepository.
+// Create implements OrderRepository. The order and its items are written in
+// one transaction.
 func (o *orderRepo) Create(ctx context.Context, order *models.Order) error {
+	tx, err := o.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertOrder(ctx, tx, order); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit order: %w", err)
+	}
+	return nil
+}
+
+// CreateFromCart implements OrderRepository. In a single transaction it
+// returns the cart's stock holds, locks the user's cart_items and the
+// referenced products, moves the ordered quantity into order-owned stock
+// reservations, snapshots current prices into order_items, stores the order
+// and clears the cart. Items and TotalPrice of order are filled from the
+// cart in order.Currency, using the variant's price override converted at
+// order.ExchangeRate, the explicit product price in that currency or else the
+// UAH price converted; any error rolls everything back. A cart line without
+// a variant of a product that has variants is ErrProductUnavailable.
+func (o *orderRepo) CreateFromCart(ctx context.Context, order *models.Order) error {
+	tx, err := o.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Тимчасові резерви кошика повертаються на склад і одразу
+	// переходять у резерви замовлення нижче
+	if err := releaseOwnerHolds(ctx, tx, models.ReservationOwnerCart, order.UserID); err != nil {
+		return err
+	}
+
+	// Блокуємо рядки в порядку product_id, щоб уникнути взаємних блокувань.
+	// Залишок варіанта захищає умова в adjustStock
+	query := `
+		SELECT ci.product_id, ci.variant_id, ci.quantity, p.price, v.price AS variant_price,
+			pp.price AS explicit_price, COALESCE(v.stock, p.stock) AS stock, p.deleted_at,
+			(ci.variant_id IS NULL AND EXISTS (
+				SELECT 1 FROM product_variants pv WHERE pv.product_id = p.id
+			)) AS needs_variant
+		FROM cart_items ci
+		JOIN products p ON p.id = ci.product_id
+		LEFT JOIN product_variants v ON v.id = ci.variant_id
+		LEFT JOIN product_prices pp ON pp.product_id = p.id AND pp.currency = $2
+		WHERE ci.user_id = $1
+		ORDER BY ci.product_id, ci.variant_id
+		FOR UPDATE OF ci, p
+	`
+	var lines []struct {
+		ProductID     uuid.UUID       `db:"product_id"`
+		VariantID     *uuid.UUID      `db:"variant_id"`
+		Quantity      int             `db:"quantity"`
+		Price         models.Money    `db:"price"`
+		VariantPrice  *models.Money   `db:"variant_price"`
+		ExplicitPrice *models.Decimal `db:"explicit_price"`
+		Stock         int             `db:"stock"`
+		DeletedAt     *time.Time      `db:"deleted_at"`
+		NeedsVariant  bool            `db:"needs_variant"`
+	}
+	if err := tx.SelectContext(ctx, &lines, query, order.UserID, order.Currency); err != nil {
+		return fmt.Errorf("failed to lock cart items: %w", err)
+	}
+	if len(lines) == 0 {
+		return ErrEmptyCart
+	}
+
+	order.Items = make([]*models.OrderItem, 0, len(lines))
+	order.TotalPrice = models.NewMoney(0, order.Currency)
+	for _, line := range lines {
+		if line.DeletedAt != nil || line.NeedsVariant {
+			return fmt.Errorf("product %s: %w", line.ProductID, ErrProductUnavailable)
+		}
+		if line.Stock < line.Quantity {
+			return fmt.Errorf("product %s: %w", line.ProductID, ErrInsufficientStock)
+		}
+
+		if err := adjustStock(ctx, tx, line.ProductID, line.VariantID, -line.Quantity); err != nil {
+			return err
+		}
+		err := insertReservation(ctx, tx, &models.StockReservation{
+			ID:        uuid.New(),
+			ProductID: line.ProductID,
+			VariantID: line.VariantID,
+			OwnerType: models.ReservationOwnerOrder,
+			OwnerID:   order.ID,
+			Quantity:  line.Quantity,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to reserve stock for order: %w", err)
+		}
+
+		// Ціна варіанта, далі явна ціна у валюті замовлення, далі конвертація
+		price := line.Price.Convert(order.Currency, order.ExchangeRate, models.RoundHalfUp)
+		switch {
+		case line.VariantPrice != nil:
+			price = line.VariantPrice.Convert(order.Currency, order.ExchangeRate, models.RoundHalfUp)
+		case line.ExplicitPrice != nil:
+			if price, err = line.ExplicitPrice.In(order.Currency); err != nil {
+				return fmt.Errorf("failed to read product price: %w", err)
+			}
+		}
+		order.Items = append(order.Items, &models.OrderItem{
+			ID:        uuid.New(),
+			OrderID:   order.ID,
+			ProductID: line.ProductID,
+			VariantID: line.VariantID,
+			Quantity:  line.Quantity,
+			Price:     price,
+		})
+		total, err := order.TotalPrice.Add(price.Mul(line.Quantity))
+		if err != nil {
+			return fmt.Errorf("failed to total order: %w", err)
+		}
+		order.TotalPrice = total
+	}
+
+	if err := insertOrder(ctx, tx, order); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM cart_items WHERE user_id = $1`, order.UserID); err != nil {
+		return fmt.Errorf("failed to clear cart: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit order: %w", err)
+	}
+	return nil
+}
+
+// insertOrder writes the orders row and all order_items rows within tx
+func insertOrder(ctx context.Context, tx *sqlx.Tx, order *models.Order) error {
 	query := `
-		INSERT INTO orders (id, user_id, items, total_amount, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO orders (id, user_id, status, total_amount, currency, exchange_rate, shipping_address, payment_method)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at, updated_at
 	`
-	_, err := o.db.ExecContext(ctx, query,
+	err := tx.QueryRowContext(ctx, query,
 		order.ID,
 		order.UserID,
-		order.Items,
 		order.Status,
-		order.CreatedAt,
-		order.UpdatedAt,
-	)
+		order.TotalPrice,
+		order.Currency,
+		order.ExchangeRate,
+		order.ShippingAddress,
+		order.PaymentMethod,
+	).Scan(&order.CreatedAt, &order.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create order: %w", err)
 	}
-	return nil
-}
 
-// Cancel implements OrderRepository.
-func (o *orderRepo) Cancel(ctx context.Context, id uuid.UUID) error {
-	query := `
-		UPDATE orders
-		SET status = 'canceled', updated_at = NOW()
-		WHERE id = $1
+	itemQuery := `
+		INSERT INTO order_items (id, order_id, product_id, variant_id, quantity, price)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at
 	`
-	_, err := o.db.ExecContext(ctx, query, id)
-	if err != nil {
-		return fmt.Errorf("failed to cancel order: %w", err)
+	for _, item := range order.Items {
+		item.OrderID = order.ID
+		err := tx.QueryRowContext(ctx, itemQuery,
+			item.ID,
+			item.OrderID,
+			item.ProductID,
+			item.VariantID,
+			item.Quantity,
+			item.Price,
+		).Scan(&item.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create order item: %w", err)
+		}
 	}
-	return nil
+
+	return insertStatusChange(ctx, tx, order.ID, nil, order.Status, order.UserID)
 }
 
 // GetByID implements OrderRepository.
 func (o *orderRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Order, error) {
 	query := `
-		SELECT id, user_id, items, total_amount, status, created_at, updated_at
+		SELECT id, user_id, status, total_amount, currency, exchange_rate, shipping_address, payment_method, created_at, updated_at
 		FROM orders
 		WHERE id = $1
 	`
-	row := o.db.QueryRowContext(ctx, query, id)
-	var order models.Order
-	err := row.Scan(
-		&order.ID,
-		&order.UserID,
-		&order.Items,
-		&order.Status,
-		&order.CreatedAt,
-		&order.UpdatedAt,
-	)
+	var row orderRow
+	err := o.db.GetContext(ctx, &row, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order by ID: %w", notFound(err))
+	}
+	orders, err := o.loadOrders(ctx, []*orderRow{&row})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get order by ID: %w", err)
+		return nil, err
 	}
-	return &order, nil
+	return orders[0], nil
 }
 
-// List implements OrderRepository.
+// List implements OrderRepository. Orders are returned newest first; with
+// filter.Cursor set Offset is ignored and the page starts next to the
+// cursor order.
 func (o *orderRepo) List(ctx context.Context, filter *models.OrderFilter) ([]*models.Order, error) {
+	args := []interface{}{filter.UserID}
 	query := `
-		SELECT id, user_id, items, total_amount, status, created_at, updated_at
+		SELECT id, user_id, status, total_amount, currency, exchange_rate, shipping_address, payment_method, created_at, updated_at
 		FROM orders
-		WHERE ($1::uuid IS NULL OR user_id = $1)
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
-	`
-	rows, err := o.db.QueryContext(ctx, query,
-		filter.UserID,
-		filter.Limit,
-		filter.Offset,
-	)
+		WHERE ($1::uuid IS NULL OR user_id = $1)`
+
+	dir, op := "DESC", "<"
+	backward := filter.Cursor != nil && filter.Cursor.Backward
+	if backward {
+		dir, op = "ASC", ">"
+	}
+	if filter.Cursor != nil {
+		args = append(args, filter.Cursor.Key, filter.Cursor.ID)
+		query += fmt.Sprintf(" AND (created_at, id) %s ($%d::timestamptz, $%d)", op, len(args)-1, len(args))
+	}
+	query += fmt.Sprintf(" ORDER BY created_at %s, id %s", dir, dir)
+
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" LIMIT $%d", len(args))
+	if filter.Cursor == nil {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows := []*orderRow{}
+	err := o.db.SelectContext(ctx, &rows, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list orders: %w", err)
 	}
-	defer rows.Close()
-	var orders []*models.Order
-	for rows.Next() {
-		var order models.Order
-		err := rows.Scan(
-			&order.ID,
-			&order.UserID,
-			&order.Items,
-			&order.Status,
-			&order.CreatedAt,
-			&order.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan order: %w", err)
+	if backward {
+		slices.Reverse(rows)
+	}
+	return o.loadOrders(ctx, rows)
+}
+
+// orderRow and orderItemRow are scanned rows whose NUMERIC amounts are
+// converted once orders.currency is known
+type orderRow struct {
+	models.Order
+	TotalAmount models.Decimal `db:"total_amount"`
+}
+
+type orderItemRow struct {
+	models.OrderItem
+	Amount models.Decimal `db:"price"`
+}
+
+// loadOrders converts the amounts of rows and fills Items of every order
+// with one query
+func (o *orderRepo) loadOrders(ctx context.Context, rows []*orderRow) ([]*models.Order, error) {
+	orders := make([]*models.Order, 0, len(rows))
+	if len(rows) == 0 {
+		return orders, nil
+	}
+	ids := make([]uuid.UUID, 0, len(rows))
+	byID := make(map[uuid.UUID]*models.Order, len(rows))
+	for _, row := range rows {
+		order := &row.Order
+		order.Items = []*models.OrderItem{}
+		// NUMERIC колонки не знають валюти, вона зберігається в orders.currency
+		var err error
+		if order.TotalPrice, err = row.TotalAmount.In(order.Currency); err != nil {
+			return nil, fmt.Errorf("failed to read order total: %w", err)
 		}
-		orders = append(orders, &order)
+		orders = append(orders, order)
+		ids = append(ids, order.ID)
+		byID[order.ID] = order
+	}
+
+	query := `
+		SELECT id, order_id, product_id, variant_id, quantity, price, created_at
+		FROM order_items
+		WHERE order_id = ANY($1)
+		ORDER BY created_at, id
+	`
+	var items []*orderItemRow
+	if err := o.db.SelectContext(ctx, &items, query, pq.Array(ids)); err != nil {
+		return nil, fmt.Errorf("failed to list order items: %w", err)
+	}
+	for _, row := range items {
+		order, ok := byID[row.OrderID]
+		if !ok {
+			continue
+		}
+		item := &row.OrderItem
+		var err error
+		if item.Price, err = row.Amount.In(order.Currency); err != nil {
+			return nil, fmt.Errorf("failed to read order item price: %w", err)
+		}
+		order.Items = append(order.Items, item)
 	}
 	return orders, nil
 }
 
-// UpdateStatus implements OrderRepository.
-func (o *orderRepo) UpdateStatus(ctx context.Context, id uuid.UUID, status string) error {
+// UpdateStatus implements OrderRepository. The status only changes if it
+// still equals from, otherwise ErrStatusChanged is returned. Every change is
+// recorded in order_status_history in the same transaction; cancelling
+// also releases the order's stock reservations in it.
+func (o *orderRepo) UpdateStatus(ctx context.Context, id uuid.UUID, from, to string, changedBy uuid.UUID) error {
+	tx, err := o.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
 		UPDATE orders
 		SET status = $1, updated_at = NOW()
-		WHERE id = $2
+		WHERE id = $2 AND status = $3
 	`
-	_, err := o.db.ExecContext(ctx, query, status, id)
+	res, err := tx.ExecContext(ctx, query, to, id, from)
 	if err != nil {
 		return fmt.Errorf("failed to update order status: %w", err)
 	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update order status: %w", err)
+	}
+	if n == 0 {
+		return ErrStatusChanged
+	}
+
+	if err := insertStatusChange(ctx, tx, id, &from, to, changedBy); err != nil {
+		return err
+	}
+
+	// Скасоване замовлення повертає резерви на склад у тій самій
+	// транзакції, щоб збій не залишив товар заблокованим назавжди
+	if to == models.OrderStatusCancelled {
+		if err := releaseOwnerHolds(ctx, tx, models.ReservationOwnerOrder, id); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit order status: %w", err)
+	}
 	return nil
 }
+
+// ListStatusHistory implements OrderRepository.
+func (o *orderRepo) ListStatusHistory(ctx context.Context, orderID uuid.UUID) ([]*models.OrderStatusChange, error) {
+	query := `
+		SELECT id, order_id, from_status, to_status, changed_by, changed_at
+		FROM order_status_history
+		WHERE order_id = $1
+		ORDER BY changed_at, id
+	`
+	history := []*models.OrderStatusChange{}
+	if err := o.db.SelectContext(ctx, &history, query, orderID); err != nil {
+		return nil, fmt.Errorf("failed to list order status history: %w", err)
+	}
+	return history, nil
+}
+
+func insertStatusChange(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, from *string, to string, changedBy uuid.UUID) error {
+	query := `
+		INSERT INTO order_status_history (order_id, from_status, to_status, changed_by)
+		VALUES ($1, $2, $3, $4)
+	`
+	_, err := tx.ExecContext(ctx, query, orderID, from, to, changedBy)
+	if err != nil {
+		return fmt.Errorf("failed to record order status change: %w", err)
+	}
+	return nil
+}
+
+func NewOrderRepository(db *database.DB) OrderRepository {
+	return &orderRepo{db: db}
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	database "github.com/nightx1x/ecommerce/interval/db"
+	models "github.com/nightx1x/ecommerce/interval/domain"
+)
+
+type PriceRepository interface {
+	ListByProduct(ctx context.Context, productID uuid.UUID) ([]*models.ProductPrice, error)
+	ListForProducts(ctx context.Context, productIDs []uuid.UUID, currency string) (map[uuid.UUID]models.Money, error)
+	Set(ctx context.Context, price *models.ProductPrice) error
+	Delete(ctx context.Context, productID uuid.UUID, currency string) error
+}
+
+type priceRepo struct {
+	db *database.DB
+}
+
+// ListByProduct implements PriceRepository.
+func (p *priceRepo) ListByProduct(ctx context.Context, productID uuid.UUID) ([]*models.ProductPrice, error) {
+	query := `
+		SELECT product_id, currency, price, created_at, updated_at
+		FROM product_prices
+		WHERE product_id = $1
+		ORDER BY currency
+	`
+	var rows []struct {
+		models.ProductPrice
+		Amount models.Decimal `db:"price"`
+	}
+	if err := p.db.SelectContext(ctx, &rows, query, productID); err != nil {
+		return nil, fmt.Errorf("failed to list product prices: %w", err)
+	}
+	prices := make([]*models.ProductPrice, 0, len(rows))
+	for _, row := range rows {
+		price := row.ProductPrice
+		var err error
+		if price.Price, err = row.Amount.In(price.Currency); err != nil {
+			return nil, fmt.Errorf("failed to list product prices: %w", err)
+		}
+		prices = append(prices, &price)
+	}
+	return prices, nil
+}
+
+// ListForProducts implements PriceRepository. Products without an explicit
+// price in currency are missing from the map.
+func (p *priceRepo) ListForProducts(ctx context.Context, productIDs []uuid.UUID, currency string) (map[uuid.UUID]models.Money, error) {
+	prices := make(map[uuid.UUID]models.Money, len(productIDs))
+	if len(productIDs) == 0 {
+		return prices, nil
+	}
+
+	query := `
+		SELECT product_id, price
+		FROM product_prices
+		WHERE product_id = ANY($1) AND currency = $2
+	`
+	var rows []struct {
+		ProductID uuid.UUID      `db:"product_id"`
+		Price     models.Decimal `db:"price"`
+	}
+	if err := p.db.SelectContext(ctx, &rows, query, pq.Array(productIDs), currency); err != nil {
+		return nil, fmt.Errorf("failed to list product prices: %w", err)
+	}
+	for _, row := range rows {
+		price, err := row.Price.In(currency)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list product prices: %w", err)
+		}
+		prices[row.ProductID] = price
+	}
+	return prices, nil
+}
+
+// Set implements PriceRepository. An existing price in the same currency is
+// replaced.
+func (p *priceRepo) Set(ctx context.Context, price *models.ProductPrice) error {
+	query := `
+		INSERT INTO product_prices (product_id, currency, price)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (product_id, currency)
+		DO UPDATE SET price = EXCLUDED.price, updated_at = NOW()
+		RETURNING created_at, updated_at
+	`
+	err := p.db.QueryRowContext(ctx, query,
+		price.ProductID,
+		price.Currency,
+		price.Price,
+	).Scan(&price.CreatedAt, &price.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to set product price: %w", err)
+	}
+	return nil
+}
+
+// Delete implements PriceRepository.
+func (p *priceRepo) Delete(ctx context.Context, productID uuid.UUID, currency string) error {
+	query := `DELETE FROM product_prices WHERE product_id = $1 AND currency = $2`
+	res, err := p.db.ExecContext(ctx, query, productID, currency)
+	if err != nil {
+		return fmt.Errorf("failed to delete product price: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete product price: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("failed to delete product price: %w", ErrNotFound)
+	}
+	return nil
+}
+
+func NewPriceRepository(db *database.DB) PriceRepository {
+	return &priceRepo{db: db}
+}
@@ -2,10 +2,14 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	database "github.com/nightx1x/ecommerce/interval/db"
 	models "github.com/nightx1x/ecommerce/interval/domain"
 )
@@ -14,22 +18,36 @@ type ProductRepository interface {
 	Create(ctx context.Context, product *models.Product) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Product, error)
 	List(ctx context.Context, filter *models.ListFilter) ([]*models.Product, error)
-	Update(ctx context.Context, id uuid.UUID) error
+	Count(ctx context.Context, filter *models.ListFilter) (int, error)
+	Search(ctx context.Context, filter *models.ListFilter) ([]*models.ProductSearchResult, error)
+	AttributeFacets(ctx context.Context, filter *models.ListFilter, attributeIDs []uuid.UUID) ([]*models.FacetCount, error)
+	PriceBounds(ctx context.Context, filter *models.ListFilter) (*models.Money, *models.Money, error)
+	PriceHistogram(ctx context.Context, filter *models.ListFilter, step models.Money) (map[int64]int, error)
+	Update(ctx context.Context, product *models.Product) error
 	UpdateStock(ctx context.Context, id uuid.UUID, quantity int) error
-	Delete(ctx context.Context, id uuid.UUID) error
+	Archive(ctx context.Context, id uuid.UUID) error
+	Restore(ctx context.Context, id uuid.UUID) error
 }
 
 type productRepo struct {
 	db *database.DB
 }
 
-// Create implements ProductRepository.
+// Create implements ProductRepository. product.Variants are created in the
+// same transaction; Stock then holds their total.
 func (p *productRepo) Create(ctx context.Context, product *models.Product) error {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
-		INSERT INTO products (id, name, description, price, stock, category_id, image_url, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO products (id, name, description, price, stock, category_id, image_url)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING version, created_at, updated_at
 	`
-	_, err := p.db.ExecContext(ctx, query,
+	err = tx.QueryRowContext(ctx, query,
 		product.ID,
 		product.Name,
 		product.Description,
@@ -37,24 +55,74 @@ func (p *productRepo) Create(ctx context.Context, product *models.Product) error
 		product.Stock,
 		product.CategoryID,
 		product.ImageURL,
-		product.CreatedAt,
-		product.UpdatedAt,
-	)
+	).Scan(&product.Version, &product.CreatedAt, &product.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create product: %w", err)
 	}
+
+	if len(product.Variants) > 0 {
+		for _, variant := range product.Variants {
+			variant.ProductID = product.ID
+			if err := insertVariant(ctx, tx, variant); err != nil {
+				return err
+			}
+		}
+		// Тригер перерахував залишок і версію товару
+		query = `SELECT stock, version, updated_at FROM products WHERE id = $1`
+		err = tx.QueryRowContext(ctx, query, product.ID).Scan(&product.Stock, &product.Version, &product.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create product: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit product: %w", err)
+	}
 	return nil
 }
 
-// Delete implements ProductRepository.
-func (p *productRepo) Delete(ctx context.Context, id uuid.UUID) error {
+// Archive implements ProductRepository. The product is soft-deleted so
+// order_items referencing it stay intact.
+func (p *productRepo) Archive(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE products
+		SET deleted_at = NOW(), version = version + 1, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	return p.setArchived(ctx, query, id)
+}
+
+// Restore implements ProductRepository.
+func (p *productRepo) Restore(ctx context.Context, id uuid.UUID) error {
 	query := `
-		DELETE FROM products
-		WHERE id = $1
+		UPDATE products
+		SET deleted_at = NULL, version = version + 1, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NOT NULL
 	`
-	_, err := p.db.ExecContext(ctx, query, id)
+	return p.setArchived(ctx, query, id)
+}
+
+// setArchived runs an archive or restore statement. Products already in the
+// requested state are left untouched; unknown ids yield ErrNotFound.
+func (p *productRepo) setArchived(ctx context.Context, query string, id uuid.UUID) error {
+	res, err := p.db.ExecContext(ctx, query, id)
 	if err != nil {
-		return fmt.Errorf("failed to delete product: %w", err)
+		return fmt.Errorf("failed to archive product: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to archive product: %w", err)
+	}
+	if n > 0 {
+		return nil
+	}
+	var exists bool
+	query = `SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)`
+	if err := p.db.GetContext(ctx, &exists, query, id); err != nil {
+		return fmt.Errorf("failed to archive product: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("failed to archive product: %w", ErrNotFound)
 	}
 	return nil
 }
@@ -63,98 +131,315 @@ func (p *productRepo) Delete(ctx context.Context, id uuid.UUID) error {
 func (p *productRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Product, error) {
 	var product models.Product
 	query := `
-        SELECT id, name, description, price, stock, category_id, image_url, created_at, updated_at
+        SELECT id, name, description, price, stock, category_id, image_url, version, created_at, updated_at, deleted_at
         FROM products
         WHERE id = $1
     `
 
 	err := p.db.GetContext(ctx, &product, query, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get product: %w", err)
+		return nil, fmt.Errorf("failed to get product: %w", notFound(err))
 	}
 
 	return &product, nil
 }
 
-// List implements ProductRepository.
+// productSorts maps the OrderBy options to their column and the type the
+// keyset boundary is cast to
+var productSorts = map[string]struct {
+	column string
+	cast   string
+}{
+	"price":      {"price", "numeric"},
+	"name":       {"name", "text"},
+	"created_at": {"created_at", "timestamptz"},
+}
+
+// List implements ProductRepository. Rows are ordered by the sort column and
+// id; with filter.Cursor set Offset is ignored and the page starts next to
+// the cursor row. Backward pages are returned in listing order as well.
 func (p *productRepo) List(ctx context.Context, filter *models.ListFilter) ([]*models.Product, error) {
+	where, args := productConditions(filter)
+
+	field, dir := "created_at", "DESC"
+	if i := strings.LastIndex(filter.OrderBy, "_"); i > 0 {
+		if _, ok := productSorts[filter.OrderBy[:i]]; ok {
+			field, dir = filter.OrderBy[:i], strings.ToUpper(filter.OrderBy[i+1:])
+		}
+	}
+	sort := productSorts[field]
+
+	backward := filter.Cursor != nil && filter.Cursor.Backward
+	if backward {
+		dir = flipDirection(dir)
+	}
+	if filter.Cursor != nil {
+		op := ">"
+		if dir == "DESC" {
+			op = "<"
+		}
+		args = append(args, filter.Cursor.Key, filter.Cursor.ID)
+		where += fmt.Sprintf(" AND (%s, id) %s ($%d::%s, $%d)", sort.column, op, len(args)-1, sort.cast, len(args))
+	}
+
 	query := `
-	SELECT id, name, description, price, stock, category_id, image_url, created_at, updated_at
+		SELECT id, name, description, price, stock, category_id, image_url, version, created_at, updated_at, deleted_at
 		FROM products
-		WHERE 1=1
-	`
+		WHERE ` + where
+	// id робить порядок стабільним між сторінками
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s", sort.column, dir, dir)
+	// Pagination
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	if filter.Offset > 0 && filter.Cursor == nil {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	products := []*models.Product{}
+	err := p.db.SelectContext(ctx, &products, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list products: %w", err)
+	}
+
+	if backward {
+		slices.Reverse(products)
+	}
+	return products, nil
+}
+
+// Count implements ProductRepository. It counts all products matching
+// filter, ignoring Limit and Offset.
+func (p *productRepo) Count(ctx context.Context, filter *models.ListFilter) (int, error) {
+	where, args := productConditions(filter)
+	query := `SELECT COUNT(*) FROM products WHERE ` + where
+
+	var total int
+	if err := p.db.GetContext(ctx, &total, query, args...); err != nil {
+		return 0, fmt.Errorf("failed to count products: %w", err)
+	}
+	return total, nil
+}
+
+// searchConfig is the text search configuration of products.search_vector,
+// see migration 000013
+const searchConfig = "english"
+
+// headlineOptions limit snippets to a few short fragments around the matches
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5"
+
+// Search implements ProductRepository. filter.Search must be set; matches
+// are ordered by ts_rank and OrderBy and Cursor are ignored.
+func (p *productRepo) Search(ctx context.Context, filter *models.ListFilter) ([]*models.ProductSearchResult, error) {
+	where, args := productConditions(filter)
+	args = append(args, filter.Search)
+	tsquery := fmt.Sprintf("websearch_to_tsquery('%s', $%d)", searchConfig, len(args))
 
+	query := fmt.Sprintf(`
+		SELECT id, name, description, price, stock, category_id, image_url, version, created_at, updated_at, deleted_at,
+			ts_rank(search_vector, q) AS rank,
+			ts_headline('%[1]s', name, q, 'HighlightAll=true, StartSel=<mark>, StopSel=</mark>') AS name_highlight,
+			ts_headline('%[1]s', coalesce(description, ''), q, '%[2]s') AS snippet
+		FROM products, %[3]s q
+		WHERE %[4]s
+		ORDER BY rank DESC, id`, searchConfig, headlineOptions, tsquery, where)
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	results := []*models.ProductSearchResult{}
+	if err := p.db.SelectContext(ctx, &results, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to search products: %w", err)
+	}
+	return results, nil
+}
+
+// AttributeFacets implements ProductRepository. It counts the products
+// matching filter per value of each of the attributes. Text values are
+// grouped case-insensitively, as filters match them, and shown in their
+// most common spelling.
+func (p *productRepo) AttributeFacets(ctx context.Context, filter *models.ListFilter, attributeIDs []uuid.UUID) ([]*models.FacetCount, error) {
+	counts := []*models.FacetCount{}
+	if len(attributeIDs) == 0 {
+		return counts, nil
+	}
+
+	where, args := productConditions(filter)
+	args = append(args, pq.Array(attributeIDs))
+	query := fmt.Sprintf(`
+		SELECT attribute_id, MODE() WITHIN GROUP (ORDER BY value_text) AS value_text,
+			value_number, value_bool, COUNT(*) AS count
+		FROM product_attribute_values
+		WHERE attribute_id = ANY($%d)
+			AND product_id IN (SELECT id FROM products WHERE %s)
+		GROUP BY attribute_id, lower(value_text), value_number, value_bool
+		ORDER BY count DESC, lower(value_text), value_number, value_bool`, len(args), where)
+
+	if err := p.db.SelectContext(ctx, &counts, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to count attribute facets: %w", err)
+	}
+	return counts, nil
+}
+
+// PriceBounds implements ProductRepository. Both are nil if no product
+// matches filter.
+func (p *productRepo) PriceBounds(ctx context.Context, filter *models.ListFilter) (*models.Money, *models.Money, error) {
+	where, args := productConditions(filter)
+	query := `SELECT MIN(price), MAX(price) FROM products WHERE ` + where
+
+	var minPrice, maxPrice *models.Money
+	if err := p.db.QueryRowContext(ctx, query, args...).Scan(&minPrice, &maxPrice); err != nil {
+		return nil, nil, fmt.Errorf("failed to get price bounds: %w", err)
+	}
+	return minPrice, maxPrice, nil
+}
+
+// PriceHistogram implements ProductRepository. It counts the products
+// matching filter per price bucket [n*step, (n+1)*step), keyed by n.
+func (p *productRepo) PriceHistogram(ctx context.Context, filter *models.ListFilter, step models.Money) (map[int64]int, error) {
+	where, args := productConditions(filter)
+	args = append(args, step)
+	query := fmt.Sprintf(`
+		SELECT FLOOR(price / $%d::numeric)::bigint AS bucket, COUNT(*) AS count
+		FROM products
+		WHERE %s
+		GROUP BY bucket`, len(args), where)
+
+	var rows []struct {
+		Bucket int64 `db:"bucket"`
+		Count  int   `db:"count"`
+	}
+	if err := p.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to count price buckets: %w", err)
+	}
+	histogram := make(map[int64]int, len(rows))
+	for _, row := range rows {
+		histogram[row.Bucket] = row.Count
+	}
+	return histogram, nil
+}
+
+// productConditions builds the WHERE clause shared by List and Count
+func productConditions(filter *models.ListFilter) (string, []interface{}) {
+	conds := []string{"1=1"}
 	args := []interface{}{}
-	argsCount := 1
+
+	if !filter.IncludeArchived {
+		conds = append(conds, "deleted_at IS NULL")
+	}
 
 	if filter.CategoryID != nil {
-		query += fmt.Sprintf(" AND category_id = $%d", argsCount)
 		args = append(args, *filter.CategoryID)
-		argsCount++
+		if filter.IncludeSubcategories {
+			conds = append(conds, fmt.Sprintf(`category_id IN (
+				WITH RECURSIVE subtree AS (
+					SELECT id FROM categories WHERE id = $%d
+					UNION
+					SELECT c.id FROM categories c JOIN subtree ON c.parent_id = subtree.id
+				)
+				SELECT id FROM subtree
+			)`, len(args)))
+		} else {
+			conds = append(conds, fmt.Sprintf("category_id = $%d", len(args)))
+		}
 	}
 
 	if filter.MinPrice != nil {
-		query += fmt.Sprintf(" AND price >= $%d", argsCount)
 		args = append(args, *filter.MinPrice)
-		argsCount++
+		conds = append(conds, fmt.Sprintf("price >= $%d", len(args)))
 	}
 
 	if filter.MaxPrice != nil {
-		query += fmt.Sprintf(" AND price <= $%d", argsCount)
 		args = append(args, *filter.MaxPrice)
-		argsCount++
+		conds = append(conds, fmt.Sprintf("price <= $%d", len(args)))
 	}
 
-	if filter.Search != "" {
-		query += fmt.Sprintf(" AND name ILIKE $%d OR description ILIKE $%d", argsCount, argsCount)
-		args = append(args, "%"+filter.Search+"%")
-		argsCount++
-	}
-
-	orderBy := "created_at DESC"
-	if filter.OrderBy != "" {
-		allowedOrders := map[string]bool{
-			"price_asc":  true,
-			"price_desc": true,
-			"name_asc":   true,
-			"name_desc":  true,
+	if filter.InStock != nil {
+		if *filter.InStock {
+			conds = append(conds, "stock > 0")
+		} else {
+			conds = append(conds, "stock = 0")
 		}
-		if allowedOrders[filter.OrderBy] {
-			parts := strings.Split(filter.OrderBy, "_")
-			orderBy = parts[0] + " " + strings.ToUpper(parts[1])
-		}
-	}
-	query += " ORDER BY " + orderBy
-	// Pagination
-	if filter.Limit > 0 {
-		query += fmt.Sprintf(" LIMIT $%d", argsCount)
-		args = append(args, filter.Limit)
-		argsCount++
 	}
 
-	if filter.Offset > 0 {
-		query += fmt.Sprintf(" OFFSET $%d", argsCount)
-		args = append(args, filter.Offset)
-		argsCount++
+	if filter.Search != "" {
+		args = append(args, filter.Search)
+		conds = append(conds, fmt.Sprintf("search_vector @@ websearch_to_tsquery('%s', $%d)", searchConfig, len(args)))
 	}
 
-	var products []*models.Product
-	err := p.db.SelectContext(ctx, &products, query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list products: %w", err)
+	for _, f := range filter.Attributes {
+		args = append(args, f.AttributeID)
+		valueConds := []string{fmt.Sprintf("attribute_id = $%d", len(args))}
+		switch f.Type {
+		case models.AttributeText:
+			args = append(args, pq.Array(f.Values))
+			valueConds = append(valueConds, fmt.Sprintf("lower(value_text) = ANY($%d)", len(args)))
+		case models.AttributeBoolean:
+			args = append(args, pq.Array(f.Bools))
+			valueConds = append(valueConds, fmt.Sprintf("value_bool = ANY($%d)", len(args)))
+		case models.AttributeNumber:
+			if len(f.Numbers) > 0 {
+				args = append(args, pq.Array(f.Numbers))
+				valueConds = append(valueConds, fmt.Sprintf("value_number = ANY($%d)", len(args)))
+			}
+			if f.Min != nil {
+				args = append(args, *f.Min)
+				valueConds = append(valueConds, fmt.Sprintf("value_number >= $%d", len(args)))
+			}
+			if f.Max != nil {
+				args = append(args, *f.Max)
+				valueConds = append(valueConds, fmt.Sprintf("value_number <= $%d", len(args)))
+			}
+		}
+		conds = append(conds, fmt.Sprintf(
+			"id IN (SELECT product_id FROM product_attribute_values WHERE %s)",
+			strings.Join(valueConds, " AND ")))
 	}
 
-	return products, nil
+	return strings.Join(conds, " AND "), args
 }
 
-// Update implements ProductRepository.
-func (p *productRepo) Update(ctx context.Context, id uuid.UUID) error {
+// Update implements ProductRepository. The row is only written if its
+// version still equals product.Version, otherwise ErrVersionConflict is
+// returned; on success product.Version holds the new version.
+func (p *productRepo) Update(ctx context.Context, product *models.Product) error {
 	query := `
 		UPDATE products
-		SET updated_at = NOW()
-		WHERE id = $1
+		SET name = $1, description = $2, price = $3, stock = $4,
+			category_id = $5, image_url = $6, version = version + 1, updated_at = NOW()
+		WHERE id = $7 AND version = $8
+		RETURNING version, updated_at
 	`
-	_, err := p.db.ExecContext(ctx, query, id)
+	err := p.db.QueryRowContext(ctx, query,
+		product.Name,
+		product.Description,
+		product.Price,
+		product.Stock,
+		product.CategoryID,
+		product.ImageURL,
+		product.ID,
+		product.Version,
+	).Scan(&product.Version, &product.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		var exists bool
+		query = `SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)`
+		if err := p.db.GetContext(ctx, &exists, query, product.ID); err != nil {
+			return fmt.Errorf("failed to update product: %w", err)
+		}
+		if exists {
+			return ErrVersionConflict
+		}
+		return fmt.Errorf("failed to update product: %w", ErrNotFound)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to update product: %w", err)
 	}
@@ -165,7 +450,7 @@ func (p *productRepo) Update(ctx context.Context, id uuid.UUID) error {
 func (p *productRepo) UpdateStock(ctx context.Context, id uuid.UUID, quantity int) error {
 	query := `
 		UPDATE products
-		SET stock = stock + $1, updated_at = NOW()
+		SET stock = stock + $1, version = version + 1, updated_at = NOW()
 		WHERE id = $2
 	`
 	_, err := p.db.ExecContext(ctx, query, quantity, id)
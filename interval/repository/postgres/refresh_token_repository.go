@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	database "github.com/nightx1x/ecommerce/interval/db"
+	models "github.com/nightx1x/ecommerce/interval/domain"
+)
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *models.RefreshToken) error
+	GetByHash(ctx context.Context, hash string) (*models.RefreshToken, error)
+	Rotate(ctx context.Context, oldID uuid.UUID, next *models.RefreshToken) error
+	Revoke(ctx context.Context, id uuid.UUID) error
+	RevokeAllForUser(ctx context.Context, userID uuid.UUID) error
+}
+
+type refreshTokenRepo struct {
+	db *database.DB
+}
+
+// Create implements RefreshTokenRepository.
+func (r *refreshTokenRepo) Create(ctx context.Context, token *models.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (id, user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at
+	`
+	err := r.db.QueryRowContext(ctx, query,
+		token.ID,
+		token.UserID,
+		token.TokenHash,
+		token.ExpiresAt,
+	).Scan(&token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	return nil
+}
+
+// GetByHash implements RefreshTokenRepository.
+func (r *refreshTokenRepo) GetByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, revoked_at, replaced_by, created_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+	`
+	var token models.RefreshToken
+	err := r.db.GetContext(ctx, &token, query, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token: %w", notFound(err))
+	}
+	return &token, nil
+}
+
+// Rotate implements RefreshTokenRepository. It revokes the old token and
+// stores its replacement in one transaction; if the old token was already
+// revoked the returned error wraps ErrNotFound.
+func (r *refreshTokenRepo) Rotate(ctx context.Context, oldID uuid.UUID, next *models.RefreshToken) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	insert := `
+		INSERT INTO refresh_tokens (id, user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at
+	`
+	err = tx.QueryRowContext(ctx, insert,
+		next.ID,
+		next.UserID,
+		next.TokenHash,
+		next.ExpiresAt,
+	).Scan(&next.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	revoke := `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW(), replaced_by = $1
+		WHERE id = $2 AND revoked_at IS NULL
+	`
+	res, err := tx.ExecContext(ctx, revoke, next.ID, oldID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return fmt.Errorf("refresh token already revoked: %w", ErrNotFound)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Revoke implements RefreshTokenRepository.
+func (r *refreshTokenRepo) Revoke(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL
+	`
+	_, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	return nil
+}
+
+// RevokeAllForUser implements RefreshTokenRepository.
+func (r *refreshTokenRepo) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`
+	_, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke user refresh tokens: %w", err)
+	}
+	return nil
+}
+
+func NewRefreshTokenRepository(db *database.DB) RefreshTokenRepository {
+	return &refreshTokenRepo{db: db}
+}
@@ -0,0 +1,304 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	database "github.com/nightx1x/ecommerce/interval/db"
+	models "github.com/nightx1x/ecommerce/interval/domain"
+)
+
+type ReservationRepository interface {
+	Hold(ctx context.Context, reservation *models.StockReservation) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.StockReservation, error)
+	ListActiveByOwner(ctx context.Context, ownerType string, ownerID uuid.UUID) ([]*models.StockReservation, error)
+	Release(ctx context.Context, id uuid.UUID) (bool, error)
+	ExpireDue(ctx context.Context) (int64, error)
+}
+
+type reservationRepo struct {
+	db *database.DB
+}
+
+// Hold implements ReservationRepository. It sets the active reservation of
+// the owner for the product (or variant) to reservation.Quantity, creating
+// it if needed, and moves only the difference out of (or back into) the
+// stock. The
+// decrement is guarded by stock >= delta, so concurrent holds can never
+// oversell; ErrInsufficientStock is returned instead.
+func (r *reservationRepo) Hold(ctx context.Context, reservation *models.StockReservation) error {
+	err := r.hold(ctx, reservation)
+	if isUniqueViolation(err) {
+		// Паралельний запит щойно створив цей резерв; повтор його оновить
+		err = r.hold(ctx, reservation)
+	}
+	return err
+}
+
+// hold is one attempt of Hold. Two first holds of the same owner race on
+// idx_stock_reservations_active and the loser fails with a unique violation.
+func (r *reservationRepo) hold(ctx context.Context, reservation *models.StockReservation) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var existing models.StockReservation
+	query := `
+		SELECT id, product_id, variant_id, owner_type, owner_id, quantity, status, expires_at, created_at, released_at
+		FROM stock_reservations
+		WHERE product_id = $1 AND variant_id IS NOT DISTINCT FROM $2
+			AND owner_type = $3 AND owner_id = $4 AND status = 'active'
+		FOR UPDATE
+	`
+	err = tx.GetContext(ctx, &existing, query,
+		reservation.ProductID,
+		reservation.VariantID,
+		reservation.OwnerType,
+		reservation.OwnerID,
+	)
+	found := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to get reservation: %w", err)
+	}
+
+	delta := reservation.Quantity
+	if found {
+		delta -= existing.Quantity
+	}
+	if err := adjustStock(ctx, tx, reservation.ProductID, reservation.VariantID, -delta); err != nil {
+		return err
+	}
+
+	if found {
+		query = `
+			UPDATE stock_reservations
+			SET quantity = $1, expires_at = $2
+			WHERE id = $3
+			RETURNING id, status, created_at
+		`
+		err = tx.QueryRowContext(ctx, query,
+			reservation.Quantity,
+			reservation.ExpiresAt,
+			existing.ID,
+		).Scan(&reservation.ID, &reservation.Status, &reservation.CreatedAt)
+	} else {
+		err = insertReservation(ctx, tx, reservation)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save reservation: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit reservation: %w", err)
+	}
+	return nil
+}
+
+// GetByID implements ReservationRepository.
+func (r *reservationRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.StockReservation, error) {
+	query := `
+		SELECT id, product_id, variant_id, owner_type, owner_id, quantity, status, expires_at, created_at, released_at
+		FROM stock_reservations
+		WHERE id = $1
+	`
+	var reservation models.StockReservation
+	err := r.db.GetContext(ctx, &reservation, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reservation: %w", notFound(err))
+	}
+	return &reservation, nil
+}
+
+// ListActiveByOwner implements ReservationRepository.
+func (r *reservationRepo) ListActiveByOwner(ctx context.Context, ownerType string, ownerID uuid.UUID) ([]*models.StockReservation, error) {
+	query := `
+		SELECT id, product_id, variant_id, owner_type, owner_id, quantity, status, expires_at, created_at, released_at
+		FROM stock_reservations
+		WHERE owner_type = $1 AND owner_id = $2 AND status = 'active'
+		ORDER BY created_at, id
+	`
+	reservations := []*models.StockReservation{}
+	err := r.db.SelectContext(ctx, &reservations, query, ownerType, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reservations: %w", err)
+	}
+	return reservations, nil
+}
+
+// Release implements ReservationRepository. Releasing a reservation that is
+// no longer active is a no-op and reports false.
+func (r *reservationRepo) Release(ctx context.Context, id uuid.UUID) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE stock_reservations
+		SET status = 'released', released_at = NOW()
+		WHERE id = $1 AND status = 'active'
+		RETURNING product_id, variant_id, quantity
+	`
+	var productID uuid.UUID
+	var variantID *uuid.UUID
+	var quantity int
+	err = tx.QueryRowContext(ctx, query, id).Scan(&productID, &variantID, &quantity)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to release reservation: %w", err)
+	}
+
+	if err := adjustStock(ctx, tx, productID, variantID, quantity); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit reservation release: %w", err)
+	}
+	return true, nil
+}
+
+// ExpireDue implements ReservationRepository. All active reservations past
+// their expiry are marked expired and their stock returned in one statement.
+func (r *reservationRepo) ExpireDue(ctx context.Context) (int64, error) {
+	query := `
+		WITH expired AS (
+			UPDATE stock_reservations
+			SET status = 'expired', released_at = NOW()
+			WHERE status = 'active' AND expires_at IS NOT NULL AND expires_at <= NOW()
+			RETURNING product_id, variant_id, quantity
+		), returned AS (
+			UPDATE products p
+			SET stock = p.stock + e.quantity, version = p.version + 1, updated_at = NOW()
+			FROM (
+				SELECT product_id, SUM(quantity) AS quantity
+				FROM expired
+				WHERE variant_id IS NULL
+				GROUP BY product_id
+			) e
+			WHERE p.id = e.product_id
+		), returned_variants AS (
+			UPDATE product_variants v
+			SET stock = v.stock + e.quantity, version = v.version + 1, updated_at = NOW()
+			FROM (
+				SELECT variant_id, SUM(quantity) AS quantity
+				FROM expired
+				WHERE variant_id IS NOT NULL
+				GROUP BY variant_id
+			) e
+			WHERE v.id = e.variant_id
+		)
+		SELECT COUNT(*) FROM expired
+	`
+	var count int64
+	if err := r.db.GetContext(ctx, &count, query); err != nil {
+		return 0, fmt.Errorf("failed to expire reservations: %w", err)
+	}
+	return count, nil
+}
+
+// adjustStock adds delta to the stock of the variant, or of the product if
+// variantID is nil, within tx. A negative delta only succeeds if enough
+// stock is left, otherwise ErrInsufficientStock. Variant stock changes reach
+// products.stock through the trg_product_variants_sync_stock trigger.
+func adjustStock(ctx context.Context, tx *sqlx.Tx, productID uuid.UUID, variantID *uuid.UUID, delta int) error {
+	if delta == 0 {
+		return nil
+	}
+	query := `
+		UPDATE products
+		SET stock = stock + $1, version = version + 1, updated_at = NOW()
+		WHERE id = $2 AND stock + $1 >= 0
+	`
+	id := productID
+	if variantID != nil {
+		query = `
+			UPDATE product_variants
+			SET stock = stock + $1, version = version + 1, updated_at = NOW()
+			WHERE id = $2 AND stock + $1 >= 0
+		`
+		id = *variantID
+	}
+	res, err := tx.ExecContext(ctx, query, delta, id)
+	if err != nil {
+		return fmt.Errorf("failed to update product stock: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update product stock: %w", err)
+	}
+	if n == 0 {
+		if variantID != nil {
+			return fmt.Errorf("variant %s: %w", *variantID, ErrInsufficientStock)
+		}
+		return fmt.Errorf("product %s: %w", productID, ErrInsufficientStock)
+	}
+	return nil
+}
+
+func insertReservation(ctx context.Context, tx *sqlx.Tx, reservation *models.StockReservation) error {
+	query := `
+		INSERT INTO stock_reservations (id, product_id, variant_id, owner_type, owner_id, quantity, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING status, created_at
+	`
+	return tx.QueryRowContext(ctx, query,
+		reservation.ID,
+		reservation.ProductID,
+		reservation.VariantID,
+		reservation.OwnerType,
+		reservation.OwnerID,
+		reservation.Quantity,
+		reservation.ExpiresAt,
+	).Scan(&reservation.Status, &reservation.CreatedAt)
+}
+
+// releaseOwnerHolds returns the stock of all active reservations of an
+// owner within tx
+func releaseOwnerHolds(ctx context.Context, tx *sqlx.Tx, ownerType string, ownerID uuid.UUID) error {
+	query := `
+		WITH released AS (
+			UPDATE stock_reservations
+			SET status = 'released', released_at = NOW()
+			WHERE owner_type = $1 AND owner_id = $2 AND status = 'active'
+			RETURNING product_id, variant_id, quantity
+		), returned_variants AS (
+			UPDATE product_variants v
+			SET stock = v.stock + r.quantity, version = v.version + 1, updated_at = NOW()
+			FROM (
+				SELECT variant_id, SUM(quantity) AS quantity
+				FROM released
+				WHERE variant_id IS NOT NULL
+				GROUP BY variant_id
+			) r
+			WHERE v.id = r.variant_id
+		)
+		UPDATE products p
+		SET stock = p.stock + r.quantity, version = p.version + 1, updated_at = NOW()
+		FROM (
+			SELECT product_id, SUM(quantity) AS quantity
+			FROM released
+			WHERE variant_id IS NULL
+			GROUP BY product_id
+		) r
+		WHERE p.id = r.product_id
+	`
+	_, err := tx.ExecContext(ctx, query, ownerType, ownerID)
+	if err != nil {
+		return fmt.Errorf("failed to release reservations: %w", err)
+	}
+	return nil
+}
+
+func NewReservationRepository(db *database.DB) ReservationRepository {
+	return &reservationRepo{db: db}
+}
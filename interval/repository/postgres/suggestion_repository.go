@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	database "github.com/nightx1x/ecommerce/interval/db"
+	models "github.com/nightx1x/ecommerce/interval/domain"
+)
+
+// SuggestionRepository finds names close to what the user typed, using the
+// pg_trgm indexes on products.name and categories.name
+type SuggestionRepository interface {
+	Suggest(ctx context.Context, prefix string, limit int) ([]*models.Suggestion, error)
+	Correct(ctx context.Context, query string) (string, bool, error)
+}
+
+type suggestionRepo struct {
+	db *database.DB
+}
+
+// Suggest implements SuggestionRepository. Names starting with prefix come
+// first, followed by fuzzy matches ordered by word similarity, so typos such
+// as "iphnoe" still complete to "iPhone".
+func (s *suggestionRepo) Suggest(ctx context.Context, prefix string, limit int) ([]*models.Suggestion, error) {
+	query := `
+		SELECT kind, id, text, score FROM (
+			SELECT 'product' AS kind, id, name AS text,
+				CASE WHEN name ILIKE $2 THEN 1 ELSE word_similarity($1, name) END AS score
+			FROM products
+			WHERE deleted_at IS NULL AND (name ILIKE $2 OR $1 <% name)
+			UNION ALL
+			SELECT 'category', id, name,
+				CASE WHEN name ILIKE $2 THEN 1 ELSE word_similarity($1, name) END
+			FROM categories
+			WHERE name ILIKE $2 OR $1 <% name
+		) s
+		ORDER BY score DESC, length(text), text
+		LIMIT $3
+	`
+	suggestions := []*models.Suggestion{}
+	err := s.db.SelectContext(ctx, &suggestions, query, prefix, escapeLike(prefix)+"%", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest: %w", err)
+	}
+	return suggestions, nil
+}
+
+// Correct implements SuggestionRepository. It returns the product name most
+// similar to query, or false if none is similar enough.
+func (s *suggestionRepo) Correct(ctx context.Context, query string) (string, bool, error) {
+	q := `
+		SELECT name
+		FROM products
+		WHERE deleted_at IS NULL AND $1 <% name
+		ORDER BY word_similarity($1, name) DESC, length(name)
+		LIMIT 1
+	`
+	var name string
+	err := s.db.GetContext(ctx, &name, q, query)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to correct query: %w", err)
+	}
+	return name, true, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s, so user input matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func NewSuggestionRepository(db *database.DB) SuggestionRepository {
+	return &suggestionRepo{db: db}
+}
@@ -24,16 +24,18 @@ type userRepo struct {
 // Create implements UserRepository.
 func (u *userRepo) Create(ctx context.Context, user *models.User) error {
 	query := `
-		INSERT INTO users (id, email, password, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO users (id, email, password_hash, first_name, last_name, role)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at, updated_at
 	`
-	_, err := u.db.ExecContext(ctx, query,
+	err := u.db.QueryRowContext(ctx, query,
 		user.ID,
 		user.Email,
-		user.Password,
-		user.CreatedAt,
-		user.UpdatedAt,
-	)
+		user.PasswordHash,
+		user.FirstName,
+		user.LastName,
+		user.Role,
+	).Scan(&user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create user: %w", err)
 	}
@@ -56,21 +58,14 @@ func (u *userRepo) Delete(ctx context.Context, id uuid.UUID) error {
 // GetByID implements UserRepository.
 func (u *userRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	query := `
-		SELECT id, email, password, created_at, updated_at
+		SELECT id, email, password_hash, first_name, last_name, role, created_at, updated_at
 		FROM users
 		WHERE id = $1
 	`
-	row := u.db.QueryRowContext(ctx, query, id)
 	var user models.User
-	err := row.Scan(
-		&user.ID,
-		&user.Email,
-		&user.Password,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	err := u.db.GetContext(ctx, &user, query, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user by ID: %w", err)
+		return nil, fmt.Errorf("failed to get user by ID: %w", notFound(err))
 	}
 	return &user, nil
 }
@@ -78,21 +73,14 @@ func (u *userRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.User, err
 // GetByEmail implements UserRepository.
 func (u *userRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	query := `
-		SELECT id, email, password, created_at, updated_at
+		SELECT id, email, password_hash, first_name, last_name, role, created_at, updated_at
 		FROM users
 		WHERE email = $1
 	`
-	row := u.db.QueryRowContext(ctx, query, email)
 	var user models.User
-	err := row.Scan(
-		&user.ID,
-		&user.Email,
-		&user.Password,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	err := u.db.GetContext(ctx, &user, query, email)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user by email: %w", err)
+		return nil, fmt.Errorf("failed to get user by email: %w", notFound(err))
 	}
 	return &user, nil
 }
@@ -101,17 +89,20 @@ func (u *userRepo) GetByEmail(ctx context.Context, email string) (*models.User,
 func (u *userRepo) Update(ctx context.Context, user *models.User) error {
 	query := `
 		UPDATE users
-		SET email = $1, password = $2, updated_at = $3
-		WHERE id = $4
+		SET email = $1, password_hash = $2, first_name = $3, last_name = $4, role = $5, updated_at = NOW()
+		WHERE id = $6
+		RETURNING updated_at
 	`
-	_, err := u.db.ExecContext(ctx, query,
+	err := u.db.QueryRowContext(ctx, query,
 		user.Email,
-		user.Password,
-		user.UpdatedAt,
+		user.PasswordHash,
+		user.FirstName,
+		user.LastName,
+		user.Role,
 		user.ID,
-	)
+	).Scan(&user.UpdatedAt)
 	if err != nil {
-		return fmt.Errorf("failed to update user: %w", err)
+		return fmt.Errorf("failed to update user: %w", notFound(err))
 	}
 	return nil
 }
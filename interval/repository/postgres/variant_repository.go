@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	database "github.com/nightx1x/ecommerce/interval/db"
+	models "github.com/nightx1x/ecommerce/interval/domain"
+)
+
+type VariantRepository interface {
+	ListByProduct(ctx context.Context, productID uuid.UUID) ([]*models.ProductVariant, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.ProductVariant, error)
+	Create(ctx context.Context, variant *models.ProductVariant) error
+	Update(ctx context.Context, variant *models.ProductVariant) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type variantRepo struct {
+	db *database.DB
+}
+
+// ListByProduct implements VariantRepository.
+func (v *variantRepo) ListByProduct(ctx context.Context, productID uuid.UUID) ([]*models.ProductVariant, error) {
+	query := `
+		SELECT id, product_id, sku, options, price, stock, version, created_at, updated_at
+		FROM product_variants
+		WHERE product_id = $1
+		ORDER BY created_at, sku
+	`
+	variants := []*models.ProductVariant{}
+	if err := v.db.SelectContext(ctx, &variants, query, productID); err != nil {
+		return nil, fmt.Errorf("failed to list product variants: %w", err)
+	}
+	return variants, nil
+}
+
+// GetByID implements VariantRepository.
+func (v *variantRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.ProductVariant, error) {
+	query := `
+		SELECT id, product_id, sku, options, price, stock, version, created_at, updated_at
+		FROM product_variants
+		WHERE id = $1
+	`
+	var variant models.ProductVariant
+	if err := v.db.GetContext(ctx, &variant, query, id); err != nil {
+		return nil, fmt.Errorf("failed to get product variant: %w", notFound(err))
+	}
+	return &variant, nil
+}
+
+// Create implements VariantRepository. Once a product has variants its
+// stock is the total of theirs, so stock held for the product itself could
+// never be returned correctly; while such holds are active ErrProductHeld
+// is returned.
+func (v *variantRepo) Create(ctx context.Context, variant *models.ProductVariant) error {
+	tx, err := v.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Блокуємо товар, щоб резерв на нього не з'явився паралельно з перевіркою
+	var held bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM stock_reservations
+			WHERE product_id = p.id AND variant_id IS NULL AND status = 'active'
+		)
+		FROM products p
+		WHERE p.id = $1
+		FOR UPDATE OF p
+	`
+	if err := tx.GetContext(ctx, &held, query, variant.ProductID); err != nil {
+		return fmt.Errorf("failed to check product holds: %w", notFound(err))
+	}
+	if held {
+		return ErrProductHeld
+	}
+
+	if err := insertVariant(ctx, tx, variant); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit product variant: %w", err)
+	}
+	return nil
+}
+
+// Update implements VariantRepository. The row is only written if its
+// version still equals variant.Version, otherwise ErrVersionConflict is
+// returned; stock changes by reservations bump the version too, so held
+// units are never written back. The product total follows through
+// trg_product_variants_sync_stock.
+func (v *variantRepo) Update(ctx context.Context, variant *models.ProductVariant) error {
+	query := `
+		UPDATE product_variants
+		SET sku = $1, options = $2, price = $3, stock = $4, version = version + 1, updated_at = NOW()
+		WHERE id = $5 AND version = $6
+		RETURNING version, updated_at
+	`
+	err := v.db.QueryRowContext(ctx, query,
+		variant.SKU,
+		variant.Options,
+		variant.Price,
+		variant.Stock,
+		variant.ID,
+		variant.Version,
+	).Scan(&variant.Version, &variant.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		var exists bool
+		if err := v.db.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM product_variants WHERE id = $1)`, variant.ID); err != nil {
+			return fmt.Errorf("failed to update product variant: %w", err)
+		}
+		if exists {
+			return ErrVersionConflict
+		}
+		return fmt.Errorf("failed to update product variant: %w", ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update product variant: %w", err)
+	}
+	return nil
+}
+
+// Delete implements VariantRepository. Cart lines and holds of the variant
+// go with it; variants referenced by orders cannot be deleted.
+func (v *variantRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	res, err := v.db.ExecContext(ctx, `DELETE FROM product_variants WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete product variant: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete product variant: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("failed to delete product variant: %w", ErrNotFound)
+	}
+	return nil
+}
+
+// insertVariant writes a product_variants row within tx
+func insertVariant(ctx context.Context, tx *sqlx.Tx, variant *models.ProductVariant) error {
+	query := `
+		INSERT INTO product_variants (id, product_id, sku, options, price, stock)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING version, created_at, updated_at
+	`
+	err := tx.QueryRowContext(ctx, query,
+		variant.ID,
+		variant.ProductID,
+		variant.SKU,
+		variant.Options,
+		variant.Price,
+		variant.Stock,
+	).Scan(&variant.Version, &variant.CreatedAt, &variant.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create product variant: %w", err)
+	}
+	return nil
+}
+
+func NewVariantRepository(db *database.DB) VariantRepository {
+	return &variantRepo{db: db}
+}
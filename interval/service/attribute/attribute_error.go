@@ -0,0 +1,16 @@
+package attribute
+
+import "github.com/nightx1x/ecommerce/interval/apperror"
+
+var (
+	// Attribute errors
+	ErrAttributeNotFound = apperror.NotFound("attribute_not_found", "attribute not found")
+	ErrAttributeExists   = apperror.Conflict("attribute_already_exists", "attribute with this code already exists")
+	ErrUnknownAttribute  = apperror.BadRequest("unknown_attribute", "attribute does not exist")
+
+	// Validation errors
+	ErrInvalidAttributeCode  = apperror.BadRequest("invalid_attribute_code", "attribute code must be 1-50 lowercase letters, digits or underscores")
+	ErrInvalidAttributeName  = apperror.BadRequest("invalid_attribute_name", "attribute name must be 1-100 characters")
+	ErrInvalidAttributeType  = apperror.BadRequest("invalid_attribute_type", "attribute type must be text, number or boolean")
+	ErrInvalidAttributeValue = apperror.BadRequest("invalid_attribute_value", "attribute value does not match the attribute type")
+)
@@ -0,0 +1,205 @@
+package attribute
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	models "github.com/nightx1x/ecommerce/interval/domain"
+	repository "github.com/nightx1x/ecommerce/interval/repository/postgres"
+	productSrv "github.com/nightx1x/ecommerce/interval/service/product"
+)
+
+const (
+	maxCodeLength  = 50
+	maxNameLength  = 100
+	maxValueLength = 255
+)
+
+type AttributeService interface {
+	ListAttributes(ctx context.Context, filterableOnly bool) ([]*models.Attribute, error)
+	CreateAttribute(ctx context.Context, req CreateAttributeRequest) (*models.Attribute, error)
+	DeleteAttribute(ctx context.Context, id uuid.UUID) error
+	SetProductAttributes(ctx context.Context, productID uuid.UUID, req SetProductAttributesRequest) ([]*models.ProductAttribute, error)
+}
+
+// CreateAttributeRequest is the DTO for defining an attribute. Filterable
+// defaults to true.
+type CreateAttributeRequest struct {
+	Code       string  `json:"code" validate:"required,max=50"`
+	Name       string  `json:"name" validate:"required,max=100"`
+	Type       string  `json:"type" validate:"required,oneof=text number boolean"`
+	Unit       *string `json:"unit" validate:"omitempty,max=20"`
+	Filterable *bool   `json:"filterable"`
+}
+
+// SetProductAttributesRequest replaces all attribute values of a product.
+// Values are keyed by attribute code and given as JSON of the attribute
+// type, e.g. {"brand": "Apple", "capacity": 256, "waterproof": true}.
+type SetProductAttributesRequest struct {
+	Attributes map[string]json.RawMessage `json:"attributes"`
+}
+
+type service struct {
+	attributeRepo repository.AttributeRepository
+	productSrv    productSrv.ProductService
+}
+
+func NewService(attributeRepo repository.AttributeRepository, products productSrv.ProductService) AttributeService {
+	return &service{attributeRepo: attributeRepo, productSrv: products}
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// validateCode checks that code is a short snake_case identifier, as used
+// in attr.<code> query parameters
+func validateCode(code string) error {
+	if code == "" || len(code) > maxCodeLength {
+		return ErrInvalidAttributeCode
+	}
+	for _, c := range code {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '_' {
+			return ErrInvalidAttributeCode
+		}
+	}
+	return nil
+}
+
+// ListAttributes implements AttributeService.
+func (s *service) ListAttributes(ctx context.Context, filterableOnly bool) ([]*models.Attribute, error) {
+	attributes, err := s.attributeRepo.List(ctx, filterableOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attributes: %w", err)
+	}
+	return attributes, nil
+}
+
+// CreateAttribute implements AttributeService.
+func (s *service) CreateAttribute(ctx context.Context, req CreateAttributeRequest) (*models.Attribute, error) {
+	code := strings.TrimSpace(req.Code)
+	if err := validateCode(code); err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxNameLength {
+		return nil, ErrInvalidAttributeName
+	}
+	if !slices.Contains([]string{models.AttributeText, models.AttributeNumber, models.AttributeBoolean}, req.Type) {
+		return nil, ErrInvalidAttributeType
+	}
+
+	attribute := &models.Attribute{
+		ID:         uuid.New(),
+		Code:       code,
+		Name:       name,
+		Type:       req.Type,
+		Unit:       req.Unit,
+		Filterable: req.Filterable == nil || *req.Filterable,
+	}
+	if err := s.attributeRepo.Create(ctx, attribute); err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrAttributeExists
+		}
+		return nil, fmt.Errorf("failed to create attribute: %w", err)
+	}
+	return attribute, nil
+}
+
+// DeleteAttribute implements AttributeService. The attribute's values are
+// removed from all products.
+func (s *service) DeleteAttribute(ctx context.Context, id uuid.UUID) error {
+	if err := s.attributeRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrAttributeNotFound
+		}
+		return fmt.Errorf("failed to delete attribute: %w", err)
+	}
+	return nil
+}
+
+// SetProductAttributes implements AttributeService.
+func (s *service) SetProductAttributes(ctx context.Context, productID uuid.UUID, req SetProductAttributesRequest) ([]*models.ProductAttribute, error) {
+	if _, err := s.productSrv.GetProductIncludingArchived(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, len(req.Attributes))
+	for code := range req.Attributes {
+		codes = append(codes, code)
+	}
+	attributes, err := s.attributeRepo.ListByCodes(ctx, codes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set product attributes: %w", err)
+	}
+	if len(attributes) != len(codes) {
+		return nil, ErrUnknownAttribute
+	}
+
+	values := make([]*models.ProductAttribute, 0, len(attributes))
+	for _, a := range attributes {
+		value, err := parseValue(a.Type, req.Attributes[a.Code])
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, &models.ProductAttribute{AttributeID: a.ID, AttributeValue: value})
+	}
+
+	if err := s.attributeRepo.SetProductValues(ctx, productID, values); err != nil {
+		return nil, fmt.Errorf("failed to set product attributes: %w", err)
+	}
+	saved, err := s.attributeRepo.ListByProduct(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set product attributes: %w", err)
+	}
+	return saved, nil
+}
+
+// parseValue decodes a JSON value of an attribute of type typ
+func parseValue(typ string, raw json.RawMessage) (models.AttributeValue, error) {
+	var value models.AttributeValue
+	var err error
+	switch typ {
+	case models.AttributeText:
+		var text string
+		if err = strictUnmarshal(raw, &text); err == nil {
+			text = strings.TrimSpace(text)
+			if text == "" || utf8.RuneCountInString(text) > maxValueLength {
+				return value, ErrInvalidAttributeValue
+			}
+			value.Text = &text
+		}
+	case models.AttributeNumber:
+		var number float64
+		if err = strictUnmarshal(raw, &number); err == nil {
+			value.Number = &number
+		}
+	case models.AttributeBoolean:
+		var b bool
+		if err = strictUnmarshal(raw, &b); err == nil {
+			value.Bool = &b
+		}
+	}
+	if err != nil {
+		return value, ErrInvalidAttributeValue
+	}
+	return value, nil
+}
+
+// strictUnmarshal is json.Unmarshal that rejects null, which would leave v
+// at its zero value
+func strictUnmarshal(raw json.RawMessage, v any) error {
+	if len(raw) == 0 || bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return ErrInvalidAttributeValue
+	}
+	return json.Unmarshal(raw, v)
+}
@@ -0,0 +1,10 @@
+package auth
+
+import "github.com/nightx1x/ecommerce/interval/apperror"
+
+var (
+	// Token errors
+	ErrInvalidToken = apperror.Unauthorized("invalid_token", "invalid token")
+	ErrTokenExpired = apperror.Unauthorized("token_expired", "token expired")
+	ErrTokenRevoked = apperror.Unauthorized("token_revoked", "token revoked")
+)
@@ -0,0 +1,249 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	models "github.com/nightx1x/ecommerce/interval/domain"
+	repository "github.com/nightx1x/ecommerce/interval/repository/postgres"
+	userSrv "github.com/nightx1x/ecommerce/interval/service/user"
+)
+
+type AuthService interface {
+	Register(ctx context.Context, req RegisterRequest) (*TokenResponse, error)
+	Login(ctx context.Context, req LoginRequest) (*TokenResponse, error)
+	Refresh(ctx context.Context, refreshToken string) (*TokenResponse, error)
+	Logout(ctx context.Context, refreshToken string) error
+	ParseAccessToken(token string) (*Claims, error)
+}
+
+// Config holds token signing settings
+type Config struct {
+	Secret     string
+	Issuer     string
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// RegisterRequest is the DTO for creating an account
+type RegisterRequest struct {
+	Email     string `json:"email" validate:"required,email"`
+	Password  string `json:"password" validate:"required,min=8,max=72"`
+	FirstName string `json:"first_name" validate:"required,max=100"`
+	LastName  string `json:"last_name" validate:"required,max=100"`
+}
+
+// LoginRequest is the DTO for exchanging credentials for tokens
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+// TokenResponse is returned by register, login and refresh
+type TokenResponse struct {
+	AccessToken  string       `json:"access_token"`
+	RefreshToken string       `json:"refresh_token"`
+	TokenType    string       `json:"token_type"`
+	ExpiresIn    int64        `json:"expires_in"`
+	User         *models.User `json:"user,omitempty"`
+}
+
+// Claims are the JWT claims carried by access tokens
+type Claims struct {
+	UserID uuid.UUID `json:"uid"`
+	Role   string    `json:"role"`
+	jwt.RegisteredClaims
+}
+
+type service struct {
+	userSrv   userSrv.UserService
+	tokenRepo repository.RefreshTokenRepository
+	cfg       Config
+	now       func() time.Time
+}
+
+func NewService(users userSrv.UserService, tokenRepo repository.RefreshTokenRepository, cfg Config) AuthService {
+	return &service{
+		userSrv:   users,
+		tokenRepo: tokenRepo,
+		cfg:       cfg,
+		now:       time.Now,
+	}
+}
+
+// Register implements AuthService.
+func (s *service) Register(ctx context.Context, req RegisterRequest) (*TokenResponse, error) {
+	user, err := s.userSrv.CreateUser(ctx, userSrv.CreateUserRequest{
+		Email:     req.Email,
+		Password:  req.Password,
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.issueTokens(ctx, user)
+}
+
+// Login implements AuthService.
+func (s *service) Login(ctx context.Context, req LoginRequest) (*TokenResponse, error) {
+	user, err := s.userSrv.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, userSrv.ErrUserNotFound) {
+			return nil, userSrv.ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if !userSrv.CheckPassword(user.PasswordHash, req.Password) {
+		return nil, userSrv.ErrInvalidCredentials
+	}
+	return s.issueTokens(ctx, user)
+}
+
+// Refresh implements AuthService. Refresh tokens are single use: each call
+// revokes the presented token and issues a new pair. Presenting a token
+// that was already rotated revokes every session of that user.
+func (s *service) Refresh(ctx context.Context, refreshToken string) (*TokenResponse, error) {
+	stored, err := s.lookupRefreshToken(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if stored.RevokedAt != nil {
+		if err := s.tokenRepo.RevokeAllForUser(ctx, stored.UserID); err != nil {
+			return nil, err
+		}
+		return nil, ErrTokenRevoked
+	}
+	if !s.now().Before(stored.ExpiresAt) {
+		return nil, ErrTokenExpired
+	}
+
+	user, err := s.userSrv.GetUserByID(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, userSrv.ErrUserNotFound) {
+			return nil, userSrv.ErrUnauthorized
+		}
+		return nil, err
+	}
+
+	plain, next, err := s.newRefreshToken(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokenRepo.Rotate(ctx, stored.ID, next); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrTokenRevoked
+		}
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	return s.tokenResponse(user, plain)
+}
+
+// Logout implements AuthService. Revoking an already revoked token is not
+// an error.
+func (s *service) Logout(ctx context.Context, refreshToken string) error {
+	stored, err := s.lookupRefreshToken(ctx, refreshToken)
+	if err != nil {
+		return err
+	}
+	return s.tokenRepo.Revoke(ctx, stored.ID)
+}
+
+// ParseAccessToken implements AuthService.
+func (s *service) ParseAccessToken(token string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(s.cfg.Secret), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(s.cfg.Issuer),
+		jwt.WithTimeFunc(s.now),
+	)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+func (s *service) issueTokens(ctx context.Context, user *models.User) (*TokenResponse, error) {
+	plain, token, err := s.newRefreshToken(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokenRepo.Create(ctx, token); err != nil {
+		return nil, err
+	}
+	return s.tokenResponse(user, plain)
+}
+
+func (s *service) tokenResponse(user *models.User, refreshToken string) (*TokenResponse, error) {
+	now := s.now()
+	claims := Claims{
+		UserID: user.ID,
+		Role:   user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.ID.String(),
+			Issuer:    s.cfg.Issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.cfg.AccessTTL)),
+			ID:        uuid.NewString(),
+		},
+	}
+	access, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.cfg.Secret))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign access token: %w", err)
+	}
+
+	return &TokenResponse{
+		AccessToken:  access,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.cfg.AccessTTL.Seconds()),
+		User:         user,
+	}, nil
+}
+
+func (s *service) newRefreshToken(userID uuid.UUID) (string, *models.RefreshToken, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	plain := base64.RawURLEncoding.EncodeToString(buf)
+	return plain, &models.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		TokenHash: hashToken(plain),
+		ExpiresAt: s.now().Add(s.cfg.RefreshTTL),
+	}, nil
+}
+
+func (s *service) lookupRefreshToken(ctx context.Context, refreshToken string) (*models.RefreshToken, error) {
+	if refreshToken == "" {
+		return nil, ErrInvalidToken
+	}
+	stored, err := s.tokenRepo.GetByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	return stored, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,12 @@
+package cart
+
+import (
+	"github.com/nightx1x/ecommerce/interval/apperror"
+	productSrv "github.com/nightx1x/ecommerce/interval/service/product"
+)
+
+var (
+	// Cart errors
+	ErrCartItemNotFound = apperror.NotFound("cart_item_not_found", "cart item not found")
+	ErrInvalidQuantity  = productSrv.ErrInvalidQuantity
+)
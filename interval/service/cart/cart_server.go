@@ -1 +1,344 @@
-pacakge cart
\ No newline at end of file
+package cart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	models "github.com/nightx1x/ecommerce/interval/domain"
+	repository "github.com/nightx1x/ecommerce/interval/repository/postgres"
+	pricingSrv "github.com/nightx1x/ecommerce/interval/service/pricing"
+	productSrv "github.com/nightx1x/ecommerce/interval/service/product"
+)
+
+// HoldTTL is how long stock stays reserved for an item sitting in a cart
+const HoldTTL = 30 * time.Minute
+
+// maxHoldAttempts bounds how often syncHold re-holds a line that keeps
+// changing under it
+const maxHoldAttempts = 3
+
+type CartService interface {
+	GetCart(ctx context.Context, userID uuid.UUID, currency string) (*CartResponse, error)
+	AddItem(ctx context.Context, userID uuid.UUID, req AddItemRequest, currency string) (*CartResponse, error)
+	SetQuantity(ctx context.Context, userID, productID uuid.UUID, variantID *uuid.UUID, quantity int, currency string) (*CartResponse, error)
+	RemoveItem(ctx context.Context, userID, productID uuid.UUID, variantID *uuid.UUID, currency string) (*CartResponse, error)
+	ClearCart(ctx context.Context, userID uuid.UUID) error
+}
+
+// AddItemRequest is the DTO for putting a product into the cart. VariantID
+// is required for products sold in variants.
+type AddItemRequest struct {
+	ProductID uuid.UUID  `json:"product_id" validate:"required"`
+	VariantID *uuid.UUID `json:"variant_id" validate:"omitempty,uuid"`
+	Quantity  int        `json:"quantity" validate:"required,gt=0"`
+}
+
+// SetQuantityRequest is the DTO for replacing the quantity of a cart line
+type SetQuantityRequest struct {
+	Quantity int `json:"quantity" validate:"required,gt=0"`
+}
+
+// CartLine is a cart item priced with the current product or variant price
+type CartLine struct {
+	ProductID uuid.UUID             `json:"product_id"`
+	VariantID *uuid.UUID            `json:"variant_id,omitempty"`
+	SKU       string                `json:"sku,omitempty"`
+	Options   models.VariantOptions `json:"options,omitempty"`
+	Name      string                `json:"name"`
+	ImageURL  *string               `json:"image_url,omitempty"`
+	UnitPrice models.Money          `json:"unit_price"`
+	Quantity  int                   `json:"quantity"`
+	LineTotal models.Money          `json:"line_total"`
+	InStock   bool                  `json:"in_stock"`
+	// Archived lines can not be checked out and should be removed
+	Archived bool `json:"archived,omitempty"`
+}
+
+// CartResponse is the user's cart with line and grand totals in Currency
+type CartResponse struct {
+	UserID     uuid.UUID    `json:"user_id"`
+	Items      []*CartLine  `json:"items"`
+	TotalItems int          `json:"total_items"`
+	Total      models.Money `json:"total"`
+	Currency   string       `json:"currency"`
+}
+
+type service struct {
+	cartRepo   repository.CartRepository
+	productSrv productSrv.ProductService
+	pricing    pricingSrv.PricingService
+}
+
+func NewService(cartRepo repository.CartRepository, products productSrv.ProductService, pricing pricingSrv.PricingService) CartService {
+	return &service{cartRepo: cartRepo, productSrv: products, pricing: pricing}
+}
+
+// GetCart implements CartService. Prices are shown in currency, see
+// pricing.PricingService; an empty currency means models.DefaultCurrency.
+func (s *service) GetCart(ctx context.Context, userID uuid.UUID, currency string) (*CartResponse, error) {
+	quote, err := s.pricing.Quote(ctx, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.cartRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart: %w", err)
+	}
+
+	holds, err := s.productSrv.ListReservations(ctx, models.ReservationOwnerCart, userID)
+	if err != nil {
+		return nil, err
+	}
+	held := make(map[lineKey]int, len(holds))
+	for _, h := range holds {
+		held[keyOf(h.ProductID, h.VariantID)] = h.Quantity
+	}
+
+	// Кілька варіантів одного товару завантажуємо й конвертуємо один раз
+	byID := make(map[uuid.UUID]*models.Product, len(items))
+	products := make([]*models.Product, 0, len(items))
+	for _, item := range items {
+		if _, ok := byID[item.ProductID]; ok {
+			continue
+		}
+		// Архівовані товари лишаються в кошику, але недоступні
+		product, err := s.productSrv.GetProductIncludingArchived(ctx, item.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		byID[item.ProductID] = product
+		products = append(products, product)
+	}
+	if err := s.pricing.PriceProducts(ctx, quote, products...); err != nil {
+		return nil, err
+	}
+
+	cart := &CartResponse{
+		UserID:   userID,
+		Items:    make([]*CartLine, 0, len(items)),
+		Total:    models.NewMoney(0, quote.Currency),
+		Currency: quote.Currency,
+	}
+	for _, item := range items {
+		product := byID[item.ProductID]
+		price, stock := product.Price, product.Stock
+		line := &CartLine{
+			ProductID: product.ID,
+			VariantID: item.VariantID,
+			Name:      product.Name,
+			ImageURL:  product.ImageURL,
+			Quantity:  item.Quantity,
+			Archived:  product.IsArchived(),
+		}
+		if item.VariantID != nil {
+			if variant := product.Variant(*item.VariantID); variant != nil {
+				line.SKU, line.Options = variant.SKU, variant.Options
+				price, stock = variant.PriceOf(product), variant.Stock
+			}
+		} else if product.HasVariants() {
+			// Рядок з часу, коли товар ще не мав варіантів
+			stock = 0
+		}
+		line.UnitPrice = price
+		line.LineTotal = price.Mul(item.Quantity)
+		line.InStock = !product.IsArchived() && stock+held[keyOf(item.ProductID, item.VariantID)] >= item.Quantity
+		cart.Items = append(cart.Items, line)
+		cart.TotalItems += line.Quantity
+		if cart.Total, err = cart.Total.Add(line.LineTotal); err != nil {
+			return nil, fmt.Errorf("failed to total cart: %w", err)
+		}
+	}
+	return cart, nil
+}
+
+// AddItem implements CartService.
+func (s *service) AddItem(ctx context.Context, userID uuid.UUID, req AddItemRequest, currency string) (*CartResponse, error) {
+	// Валюту перевіряємо до зміни кошика, а не лише у відповіді
+	if _, err := s.pricing.Quote(ctx, currency); err != nil {
+		return nil, err
+	}
+	if req.Quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+
+	// Резервуємо сумарну кількість, а не лише додану
+	existing := 0
+	current, err := s.cartRepo.GetItem(ctx, userID, req.ProductID, req.VariantID)
+	switch {
+	case err == nil:
+		existing = current.Quantity
+	case !errors.Is(err, repository.ErrNotFound):
+		return nil, fmt.Errorf("failed to get cart item: %w", err)
+	}
+	if err := s.hold(ctx, userID, req.ProductID, req.VariantID, existing+req.Quantity); err != nil {
+		return nil, err
+	}
+
+	item := &models.CartItem{
+		ID:        uuid.New(),
+		UserID:    userID,
+		ProductID: req.ProductID,
+		VariantID: req.VariantID,
+		Quantity:  req.Quantity,
+	}
+	if err := s.cartRepo.AddItem(ctx, item); err != nil {
+		// Повертаємо резерв до того, що лежало в кошику
+		if undoErr := s.restoreHold(ctx, userID, req.ProductID, req.VariantID, existing); undoErr != nil {
+			return nil, fmt.Errorf("failed to add cart item: %w", errors.Join(err, undoErr))
+		}
+		return nil, fmt.Errorf("failed to add cart item: %w", err)
+	}
+	// Кількість сумує база, і паралельне додавання могло її змінити, тому
+	// резервуємо те, що повернув рядок кошика
+	if err := s.syncHold(ctx, item); err != nil {
+		// Забираємо додане назад, щоб кошик не показував більше, ніж зарезервовано
+		if undoErr := s.cartRepo.SubtractItem(ctx, userID, req.ProductID, req.VariantID, req.Quantity); undoErr != nil {
+			return nil, fmt.Errorf("failed to undo cart item: %w", errors.Join(err, undoErr))
+		}
+		return nil, err
+	}
+	return s.GetCart(ctx, userID, currency)
+}
+
+// SetQuantity implements CartService.
+func (s *service) SetQuantity(ctx context.Context, userID, productID uuid.UUID, variantID *uuid.UUID, quantity int, currency string) (*CartResponse, error) {
+	// Валюту перевіряємо до зміни кошика, а не лише у відповіді
+	if _, err := s.pricing.Quote(ctx, currency); err != nil {
+		return nil, err
+	}
+	if quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+	if err := s.hold(ctx, userID, productID, variantID, quantity); err != nil {
+		return nil, err
+	}
+
+	item := &models.CartItem{
+		ID:        uuid.New(),
+		UserID:    userID,
+		ProductID: productID,
+		VariantID: variantID,
+		Quantity:  quantity,
+	}
+	if err := s.cartRepo.SetQuantity(ctx, item); err != nil {
+		return nil, fmt.Errorf("failed to set cart item quantity: %w", err)
+	}
+	return s.GetCart(ctx, userID, currency)
+}
+
+// RemoveItem implements CartService.
+func (s *service) RemoveItem(ctx context.Context, userID, productID uuid.UUID, variantID *uuid.UUID, currency string) (*CartResponse, error) {
+	// Валюту перевіряємо до зміни кошика, а не лише у відповіді
+	if _, err := s.pricing.Quote(ctx, currency); err != nil {
+		return nil, err
+	}
+	removed, err := s.cartRepo.RemoveItem(ctx, userID, productID, variantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove cart item: %w", err)
+	}
+	if !removed {
+		return nil, ErrCartItemNotFound
+	}
+	key := keyOf(productID, variantID)
+	if err := s.releaseHolds(ctx, userID, &key); err != nil {
+		return nil, err
+	}
+	return s.GetCart(ctx, userID, currency)
+}
+
+// ClearCart implements CartService.
+func (s *service) ClearCart(ctx context.Context, userID uuid.UUID) error {
+	if err := s.cartRepo.Clear(ctx, userID); err != nil {
+		return fmt.Errorf("failed to clear cart: %w", err)
+	}
+	return s.releaseHolds(ctx, userID, nil)
+}
+
+// lineKey identifies a cart line and its hold: a product, or one of its
+// variants. VariantID is uuid.Nil for products without variants.
+type lineKey struct {
+	ProductID uuid.UUID
+	VariantID uuid.UUID
+}
+
+func keyOf(productID uuid.UUID, variantID *uuid.UUID) lineKey {
+	key := lineKey{ProductID: productID}
+	if variantID != nil {
+		key.VariantID = *variantID
+	}
+	return key
+}
+
+// hold reserves quantity of the product (or variant) for the user's cart
+// for HoldTTL
+func (s *service) hold(ctx context.Context, userID, productID uuid.UUID, variantID *uuid.UUID, quantity int) error {
+	_, err := s.productSrv.ReserveStock(ctx, productSrv.ReserveStockRequest{
+		ProductID: productID,
+		VariantID: variantID,
+		OwnerType: models.ReservationOwnerCart,
+		OwnerID:   userID,
+		Quantity:  quantity,
+		TTL:       HoldTTL,
+	})
+	return err
+}
+
+// syncHold reserves the quantity of the cart line item. Holds set an
+// absolute quantity, so a concurrent add to the same line could overwrite a
+// newer hold with an older one; the line is re-read after each hold and held
+// again until both agree.
+func (s *service) syncHold(ctx context.Context, item *models.CartItem) error {
+	quantity := item.Quantity
+	for attempt := 0; attempt < maxHoldAttempts; attempt++ {
+		if err := s.hold(ctx, item.UserID, item.ProductID, item.VariantID, quantity); err != nil {
+			return err
+		}
+		current, err := s.cartRepo.GetItem(ctx, item.UserID, item.ProductID, item.VariantID)
+		if errors.Is(err, repository.ErrNotFound) {
+			// Рядок видалили паралельно, разом з ним звільнено й резерв
+			key := keyOf(item.ProductID, item.VariantID)
+			return s.releaseHolds(ctx, item.UserID, &key)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get cart item: %w", err)
+		}
+		if current.Quantity == quantity {
+			return nil
+		}
+		quantity = current.Quantity
+	}
+	// Рядок досі змінюється; GetCart покаже його як відсутній на складі, а
+	// оформлення замовлення однаково перевіряє залишок під блокуванням
+	return nil
+}
+
+// restoreHold sets the line's hold back to quantity, releasing it if the
+// line was not in the cart
+func (s *service) restoreHold(ctx context.Context, userID, productID uuid.UUID, variantID *uuid.UUID, quantity int) error {
+	if quantity > 0 {
+		return s.hold(ctx, userID, productID, variantID, quantity)
+	}
+	key := keyOf(productID, variantID)
+	return s.releaseHolds(ctx, userID, &key)
+}
+
+// releaseHolds returns the cart's reserved stock, for one line or all
+func (s *service) releaseHolds(ctx context.Context, userID uuid.UUID, line *lineKey) error {
+	holds, err := s.productSrv.ListReservations(ctx, models.ReservationOwnerCart, userID)
+	if err != nil {
+		return err
+	}
+	for _, h := range holds {
+		if line != nil && keyOf(h.ProductID, h.VariantID) != *line {
+			continue
+		}
+		if err := s.productSrv.ReleaseStock(ctx, h.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package category
+
+import "github.com/nightx1x/ecommerce/interval/apperror"
+
+var (
+	// Category errors
+	ErrCategoryNotFound      = apperror.NotFound("category_not_found", "category not found")
+	ErrCategoryAlreadyExists = apperror.Conflict("category_already_exists", "category already exists")
+	ErrParentNotFound        = apperror.BadRequest("parent_category_not_found", "parent category not found")
+	ErrCategoryCycle         = apperror.Conflict("category_cycle", "category cannot be moved under itself or its subcategory")
+
+	// Validation errors
+	ErrCategoryNameRequired = apperror.BadRequest("category_name_required", "category name is required")
+	ErrInvalidCategoryName  = apperror.BadRequest("invalid_category_name", "category name must be at most 100 characters")
+)
@@ -0,0 +1,235 @@
+package category
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	models "github.com/nightx1x/ecommerce/interval/domain"
+	repository "github.com/nightx1x/ecommerce/interval/repository/postgres"
+)
+
+const maxNameLength = 100
+
+type CategoryService interface {
+	CreateCategory(ctx context.Context, req CreateCategoryRequest) (*models.Category, error)
+	GetCategory(ctx context.Context, id uuid.UUID) (*models.Category, error)
+	ListCategories(ctx context.Context) ([]*models.CategoryWithCount, error)
+	UpdateCategory(ctx context.Context, id uuid.UUID, req UpdateCategoryRequest) (*models.Category, error)
+	DeleteCategory(ctx context.Context, id uuid.UUID) error
+	GetTree(ctx context.Context) ([]*models.CategoryNode, error)
+	GetBreadcrumbs(ctx context.Context, id uuid.UUID) ([]*models.Category, error)
+}
+
+// CreateCategoryRequest is the DTO for creating a category
+type CreateCategoryRequest struct {
+	Name        string     `json:"name" validate:"required,max=100"`
+	Description string     `json:"description"`
+	ParentID    *uuid.UUID `json:"parent_id"`
+}
+
+// UpdateCategoryRequest is the DTO for updating a category
+type UpdateCategoryRequest struct {
+	Name        *string `json:"name" validate:"omitempty,max=100"`
+	Description *string `json:"description"`
+	// ParentID moves the category; the nil UUID moves it to the top level
+	ParentID *uuid.UUID `json:"parent_id"`
+}
+
+type service struct {
+	categoryRepo repository.CategoryRepository
+}
+
+func NewService(categoryRepo repository.CategoryRepository) CategoryService {
+	return &service{categoryRepo: categoryRepo}
+}
+
+func validateName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", ErrCategoryNameRequired
+	}
+	if utf8.RuneCountInString(name) > maxNameLength {
+		return "", ErrInvalidCategoryName
+	}
+	return name, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// CreateCategory implements CategoryService.
+func (s *service) CreateCategory(ctx context.Context, req CreateCategoryRequest) (*models.Category, error) {
+	name, err := validateName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	var description *string
+	if req.Description != "" {
+		description = &req.Description
+	}
+
+	if req.ParentID != nil {
+		if err := s.checkParent(ctx, *req.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
+	category := &models.Category{
+		ID:          uuid.New(),
+		Name:        name,
+		Description: description,
+		ParentID:    req.ParentID,
+	}
+	if err := s.categoryRepo.Create(ctx, category); err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrCategoryAlreadyExists
+		}
+		return nil, fmt.Errorf("failed to create category: %w", err)
+	}
+	return category, nil
+}
+
+// GetCategory implements CategoryService.
+func (s *service) GetCategory(ctx context.Context, id uuid.UUID) (*models.Category, error) {
+	category, err := s.categoryRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+	return category, nil
+}
+
+// ListCategories implements CategoryService.
+func (s *service) ListCategories(ctx context.Context) ([]*models.CategoryWithCount, error) {
+	categories, err := s.categoryRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	return categories, nil
+}
+
+// UpdateCategory implements CategoryService.
+func (s *service) UpdateCategory(ctx context.Context, id uuid.UUID, req UpdateCategoryRequest) (*models.Category, error) {
+	category, err := s.GetCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		if category.Name, err = validateName(*req.Name); err != nil {
+			return nil, err
+		}
+	}
+	if req.Description != nil {
+		category.Description = req.Description
+	}
+	if req.ParentID != nil {
+		if *req.ParentID == uuid.Nil {
+			category.ParentID = nil
+		} else {
+			if err := s.checkMove(ctx, id, *req.ParentID); err != nil {
+				return nil, err
+			}
+			category.ParentID = req.ParentID
+		}
+	}
+
+	if err := s.categoryRepo.Update(ctx, category); err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrCategoryAlreadyExists
+		}
+		return nil, fmt.Errorf("failed to update category: %w", err)
+	}
+	return category, nil
+}
+
+// DeleteCategory implements CategoryService. Products of the category keep
+// existing with category_id set to NULL.
+func (s *service) DeleteCategory(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.GetCategory(ctx, id); err != nil {
+		return err
+	}
+	if err := s.categoryRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+	return nil
+}
+
+// GetTree implements CategoryService.
+func (s *service) GetTree(ctx context.Context) ([]*models.CategoryNode, error) {
+	categories, err := s.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[uuid.UUID]*models.CategoryNode, len(categories))
+	for _, c := range categories {
+		nodes[c.ID] = &models.CategoryNode{CategoryWithCount: *c, Children: []*models.CategoryNode{}}
+	}
+
+	roots := []*models.CategoryNode{}
+	// categories вже відсортовані за назвою, тож діти теж будуть упорядковані
+	for _, c := range categories {
+		node := nodes[c.ID]
+		if c.ParentID != nil {
+			if parent, ok := nodes[*c.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+	return roots, nil
+}
+
+// GetBreadcrumbs implements CategoryService. The result starts at the root
+// and ends with the requested category.
+func (s *service) GetBreadcrumbs(ctx context.Context, id uuid.UUID) ([]*models.Category, error) {
+	path, err := s.categoryRepo.Ancestors(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get breadcrumbs: %w", err)
+	}
+	if len(path) == 0 {
+		return nil, ErrCategoryNotFound
+	}
+	return path, nil
+}
+
+func (s *service) checkParent(ctx context.Context, parentID uuid.UUID) error {
+	exists, err := s.categoryRepo.Exists(ctx, parentID)
+	if err != nil {
+		return fmt.Errorf("failed to check parent category: %w", err)
+	}
+	if !exists {
+		return ErrParentNotFound
+	}
+	return nil
+}
+
+// checkMove rejects moving a category under itself or one of its descendants
+func (s *service) checkMove(ctx context.Context, id, parentID uuid.UUID) error {
+	if id == parentID {
+		return ErrCategoryCycle
+	}
+	path, err := s.categoryRepo.Ancestors(ctx, parentID)
+	if err != nil {
+		return fmt.Errorf("failed to check parent category: %w", err)
+	}
+	if len(path) == 0 {
+		return ErrParentNotFound
+	}
+	for _, c := range path {
+		if c.ID == id {
+			return ErrCategoryCycle
+		}
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package image
+
+import (
+	"net/http"
+
+	"github.com/nightx1x/ecommerce/interval/apperror"
+)
+
+var (
+	// Image errors
+	ErrImageNotFound = apperror.NotFound("image_not_found", "product image not found")
+
+	// Upload errors
+	ErrEmptyImage            = apperror.BadRequest("empty_image", "image file is empty")
+	ErrImageTooLarge         = apperror.New(http.StatusRequestEntityTooLarge, "image_too_large", "image file is too large")
+	ErrUnsupportedImageType  = apperror.New(http.StatusUnsupportedMediaType, "unsupported_image_type", "image must be a JPEG, PNG or GIF")
+	ErrInvalidImage          = apperror.BadRequest("invalid_image", "image file could not be decoded")
+	ErrInvalidImageDimension = apperror.BadRequest("invalid_image_dimensions", "image must be at most 5000 pixels wide and high")
+
+	// Validation errors
+	ErrInvalidAltText  = apperror.BadRequest("invalid_alt_text", "alt text must be at most 255 characters")
+	ErrInvalidPosition = apperror.BadRequest("invalid_image_position", "image position must not be negative")
+)
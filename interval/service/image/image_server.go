@@ -0,0 +1,309 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	models "github.com/nightx1x/ecommerce/interval/domain"
+	repository "github.com/nightx1x/ecommerce/interval/repository/postgres"
+	productSrv "github.com/nightx1x/ecommerce/interval/service/product"
+	"github.com/nightx1x/ecommerce/interval/storage"
+)
+
+const (
+	maxAltTextLength = 255
+	maxDimension     = 5000
+)
+
+// imageTypes maps the accepted content types to file extensions
+var imageTypes = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+type ImageService interface {
+	ListImages(ctx context.Context, productID uuid.UUID) ([]*models.ProductImage, error)
+	UploadImage(ctx context.Context, productID uuid.UUID, req UploadImageRequest) (*models.ProductImage, error)
+	UpdateImage(ctx context.Context, productID, imageID uuid.UUID, req UpdateImageRequest) (*models.ProductImage, error)
+	DeleteImage(ctx context.Context, productID, imageID uuid.UUID) error
+}
+
+// UploadImageRequest carries an uploaded file. The content type is sniffed
+// from the data, not taken from the client.
+type UploadImageRequest struct {
+	File    io.Reader
+	AltText string
+}
+
+// UpdateImageRequest is a merge patch of an image. Position moves the image
+// within the gallery; 0 makes it the product's main image.
+type UpdateImageRequest struct {
+	AltText  productSrv.PatchField[string] `json:"alt_text"`
+	Position *int                          `json:"position"`
+}
+
+type service struct {
+	imageRepo     repository.ImageRepository
+	productSrv    productSrv.ProductService
+	storage       storage.Storage
+	maxUploadSize int64
+}
+
+func NewService(imageRepo repository.ImageRepository, products productSrv.ProductService, store storage.Storage, maxUploadSize int64) ImageService {
+	return &service{
+		imageRepo:     imageRepo,
+		productSrv:    products,
+		storage:       store,
+		maxUploadSize: maxUploadSize,
+	}
+}
+
+// ListImages implements ImageService.
+func (s *service) ListImages(ctx context.Context, productID uuid.UUID) ([]*models.ProductImage, error) {
+	if _, err := s.productSrv.GetProductIncludingArchived(ctx, productID); err != nil {
+		return nil, err
+	}
+	images, err := s.imageRepo.ListByProduct(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list product images: %w", err)
+	}
+	return images, nil
+}
+
+// UploadImage implements ImageService. The original and its thumbnails are
+// stored before the image is recorded, and removed again if that fails.
+func (s *service) UploadImage(ctx context.Context, productID uuid.UUID, req UploadImageRequest) (*models.ProductImage, error) {
+	if _, err := s.productSrv.GetProductIncludingArchived(ctx, productID); err != nil {
+		return nil, err
+	}
+	altText, err := normalizeAltText(&req.AltText)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(req.File, s.maxUploadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, ErrEmptyImage
+	}
+	if int64(len(data)) > s.maxUploadSize {
+		return nil, ErrImageTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := imageTypes[contentType]
+	if !ok {
+		return nil, ErrUnsupportedImageType
+	}
+	// Розміри перевіряємо до повного декодування, щоб не розпаковувати
+	// "zip-бомби" на гігапікселі
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width < 1 || cfg.Height < 1 || cfg.Width > maxDimension || cfg.Height > maxDimension {
+		return nil, ErrInvalidImageDimension
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	img := &models.ProductImage{
+		ID:          uuid.New(),
+		ProductID:   productID,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+		AltText:     altText,
+		Thumbnails:  models.Thumbnails{},
+	}
+	img.StorageKey = fmt.Sprintf("products/%s/%s.%s", productID, img.ID, ext)
+	img.URL = s.storage.URL(img.StorageKey)
+
+	if err := s.storage.Put(ctx, img.StorageKey, bytes.NewReader(data), contentType); err != nil {
+		return nil, fmt.Errorf("failed to store image: %w", err)
+	}
+	if err := s.storeThumbnails(ctx, img, src); err != nil {
+		s.removeFiles(img)
+		return nil, err
+	}
+
+	if err := s.imageRepo.Create(ctx, img); err != nil {
+		s.removeFiles(img)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, productSrv.ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to create product image: %w", err)
+	}
+	return img, nil
+}
+
+// storeThumbnails generates and stores a thumbnail for every fixed width
+// below the original's.
+func (s *service) storeThumbnails(ctx context.Context, img *models.ProductImage, src image.Image) error {
+	contentType := thumbnailType(img.ContentType)
+	base := strings.TrimSuffix(img.StorageKey, "."+imageTypes[img.ContentType])
+
+	var rgba *image.RGBA
+	for _, width := range thumbnailWidths {
+		if width >= img.Width {
+			break
+		}
+		if rgba == nil {
+			rgba = toRGBA(src)
+		}
+		thumb := thumbnail(rgba, width)
+		data, err := encode(thumb, contentType)
+		if err != nil {
+			return err
+		}
+		key := thumbnailKey(base, width, contentType)
+		if err := s.storage.Put(ctx, key, bytes.NewReader(data), contentType); err != nil {
+			return fmt.Errorf("failed to store thumbnail: %w", err)
+		}
+		img.Thumbnails = append(img.Thumbnails, models.Thumbnail{
+			Width:  width,
+			Height: thumb.Rect.Dy(),
+			URL:    s.storage.URL(key),
+		})
+	}
+	return nil
+}
+
+// thumbnailType is the format thumbnails of an original of contentType are
+// encoded in
+func thumbnailType(contentType string) string {
+	if contentType == "image/jpeg" {
+		return contentType
+	}
+	return "image/png"
+}
+
+// thumbnailKey derives the storage key of a thumbnail from the original's
+// key without extension, e.g. products/<id>/<image>_w480.jpg
+func thumbnailKey(base string, width int, contentType string) string {
+	return fmt.Sprintf("%s_w%d.%s", base, width, imageTypes[contentType])
+}
+
+// files returns the storage keys of an image's original and thumbnails
+func files(img *models.ProductImage) []string {
+	contentType := thumbnailType(img.ContentType)
+	base := strings.TrimSuffix(img.StorageKey, "."+imageTypes[img.ContentType])
+
+	keys := []string{img.StorageKey}
+	for _, t := range img.Thumbnails {
+		keys = append(keys, thumbnailKey(base, t.Width, contentType))
+	}
+	return keys
+}
+
+// removeFiles deletes the stored files of img. Failures only leave orphaned
+// files behind, so they are logged rather than returned.
+func (s *service) removeFiles(img *models.ProductImage) {
+	// Запит клієнта міг бути скасований — прибираємо з власним контекстом
+	ctx := context.Background()
+	for _, key := range files(img) {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			log.Printf("failed to remove image file %s: %v", key, err)
+		}
+	}
+}
+
+// getImage loads an image and checks that it belongs to productID
+func (s *service) getImage(ctx context.Context, productID, imageID uuid.UUID) (*models.ProductImage, error) {
+	img, err := s.imageRepo.GetByID(ctx, imageID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrImageNotFound
+		}
+		return nil, fmt.Errorf("failed to get product image: %w", err)
+	}
+	if img.ProductID != productID {
+		return nil, ErrImageNotFound
+	}
+	return img, nil
+}
+
+// UpdateImage implements ImageService.
+func (s *service) UpdateImage(ctx context.Context, productID, imageID uuid.UUID, req UpdateImageRequest) (*models.ProductImage, error) {
+	img, err := s.getImage(ctx, productID, imageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.AltText.Set {
+		if img.AltText, err = normalizeAltText(req.AltText.Value); err != nil {
+			return nil, err
+		}
+	}
+	if req.Position != nil && *req.Position < 0 {
+		return nil, ErrInvalidPosition
+	}
+
+	if req.AltText.Set {
+		if err := s.imageRepo.UpdateAltText(ctx, img); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, ErrImageNotFound
+			}
+			return nil, fmt.Errorf("failed to update product image: %w", err)
+		}
+	}
+	if req.Position != nil && *req.Position != img.Position {
+		if err := s.imageRepo.Move(ctx, img, *req.Position); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, ErrImageNotFound
+			}
+			return nil, fmt.Errorf("failed to move product image: %w", err)
+		}
+	}
+	return img, nil
+}
+
+// DeleteImage implements ImageService. The files are removed once the
+// image is gone from the gallery.
+func (s *service) DeleteImage(ctx context.Context, productID, imageID uuid.UUID) error {
+	img, err := s.getImage(ctx, productID, imageID)
+	if err != nil {
+		return err
+	}
+	if err := s.imageRepo.Delete(ctx, img); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrImageNotFound
+		}
+		return fmt.Errorf("failed to delete product image: %w", err)
+	}
+	s.removeFiles(img)
+	return nil
+}
+
+// normalizeAltText trims alt text; empty text clears it
+func normalizeAltText(text *string) (*string, error) {
+	if text == nil {
+		return nil, nil
+	}
+	trimmed := strings.TrimSpace(*text)
+	if trimmed == "" {
+		return nil, nil
+	}
+	if utf8.RuneCountInString(trimmed) > maxAltTextLength {
+		return nil, ErrInvalidAltText
+	}
+	return &trimmed, nil
+}
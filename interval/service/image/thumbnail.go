@@ -0,0 +1,94 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+)
+
+// thumbnailWidths are the fixed widths generated for every upload. Widths
+// not smaller than the original are skipped.
+var thumbnailWidths = []int{160, 480, 1024}
+
+const jpegQuality = 85
+
+// thumbnail downscales src to width, keeping the aspect ratio
+func thumbnail(src *image.RGBA, width int) *image.RGBA {
+	height := src.Rect.Dy() * width / src.Rect.Dx()
+	if height < 1 {
+		height = 1
+	}
+	return resize(src, width, height)
+}
+
+// toRGBA converts src to RGBA, so resize can read the pixels directly. Call
+// it once per upload: for a JPEG it copies the whole image.
+func toRGBA(src image.Image) *image.RGBA {
+	if rgba, ok := src.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	b := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Rect, src, b.Min, draw.Src)
+	return rgba
+}
+
+// resize downscales src by averaging the box of source pixels behind every
+// destination pixel, which avoids the aliasing of nearest-neighbour
+// sampling
+func resize(src *image.RGBA, width, height int) *image.RGBA {
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := span(y, height, sh)
+		for x := 0; x < width; x++ {
+			x0, x1 := span(x, width, sw)
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride+x0*4 : sy*src.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					r += int(row[i])
+					g += int(row[i+1])
+					b += int(row[i+2])
+					a += int(row[i+3])
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// span returns the source range [from, to) covered by destination pixel i
+// of n when scaling a dimension of size src down
+func span(i, n, src int) (int, int) {
+	from := i * src / n
+	to := (i + 1) * src / n
+	if to <= from {
+		to = from + 1
+	}
+	return from, to
+}
+
+// encode writes img as JPEG for image/jpeg and as PNG otherwise
+func encode(img image.Image, contentType string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,30 @@
+package order
+
+import (
+	"fmt"
+
+	"github.com/nightx1x/ecommerce/interval/apperror"
+)
+
+var (
+	// Order errors
+	ErrOrderNotFound = apperror.NotFound("order_not_found", "order not found")
+	ErrEmptyCart     = apperror.BadRequest("empty_cart", "cart is empty")
+
+	// Validation errors
+	ErrInvalidPaymentMethod   = apperror.BadRequest("invalid_payment_method", "payment method must be cash or card")
+	ErrInvalidShippingAddress = apperror.BadRequest("invalid_shipping_address", "shipping address requires full_name, street, city and country")
+	ErrInvalidStatus          = apperror.BadRequest("invalid_order_status", "invalid order status")
+	ErrInvalidCursor          = apperror.BadRequest("invalid_order_cursor", "cursor is invalid")
+)
+
+// InvalidTransitionError is returned when the requested status change is not
+// allowed by the order state machine
+type InvalidTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("cannot change order status from %s to %s", e.From, e.To)
+}
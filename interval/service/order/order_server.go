@@ -2,49 +2,252 @@ package order
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/nightx1x/ecommerce/interval/cursor"
 	models "github.com/nightx1x/ecommerce/interval/domain"
 	repository "github.com/nightx1x/ecommerce/interval/repository/postgres"
+	pricingSrv "github.com/nightx1x/ecommerce/interval/service/pricing"
+	productSrv "github.com/nightx1x/ecommerce/interval/service/product"
 )
 
 type OrderService interface {
-	CreateOrder(ctx context.Context, req CreateOrderRequset) (*OrderResponse, error)
+	CreateOrder(ctx context.Context, userID uuid.UUID, req CreateOrderRequest) (*OrderResponse, error)
 	GetOrder(ctx context.Context, id uuid.UUID) (*OrderResponse, error)
-	ListOrder(ctx context.Context, filter *models.OrderFilter) ([]*OrderResponse, error)
-	UpdateOrderStatus(ctx context.Context, id uuid.UUID, status string) (*OrderResponse, error)
-	CancelOrder(ctx context.Context, id uuid.UUID) error
+	ListOrder(ctx context.Context, filter *models.OrderFilter, after string) (*OrderListResponse, error)
+	UpdateOrderStatus(ctx context.Context, id uuid.UUID, status string, changedBy uuid.UUID) (*OrderResponse, error)
+	CancelOrder(ctx context.Context, id uuid.UUID, changedBy uuid.UUID) (*OrderResponse, error)
+	GetStatusHistory(ctx context.Context, id uuid.UUID) ([]*models.OrderStatusChange, error)
+}
+
+// transitions declares the order state machine: status -> allowed next statuses
+var transitions = map[string][]string{
+	models.OrderStatusPending:   {models.OrderStatusConfirmed, models.OrderStatusCancelled},
+	models.OrderStatusConfirmed: {models.OrderStatusShipped, models.OrderStatusCancelled},
+	models.OrderStatusShipped:   {models.OrderStatusDelivered},
+	models.OrderStatusDelivered: {},
+	models.OrderStatusCancelled: {},
+}
+
+// CanTransition reports whether an order may move from one status to another
+func CanTransition(from, to string) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateStatusRequest is the DTO for changing the status of an order
+type UpdateStatusRequest struct {
+	Status string `json:"status" validate:"required,oneof=pending confirmed shipped delivered cancelled"`
+}
+
+// CreateOrderRequest is the DTO for checking out the current cart
+type CreateOrderRequest struct {
+	ShippingAddress models.ShippingAddress `json:"shipping_address" validate:"required"`
+	PaymentMethod   string                 `json:"payment_method" validate:"required,oneof=cash card"`
+	// Currency the order is placed and paid in; empty means
+	// models.DefaultCurrency
+	Currency string `json:"currency"`
+}
+
+// OrderResponse is the DTO returned for a single order
+type OrderResponse struct {
+	*models.Order
+}
+
+// OrderListResponse is a page of orders with cursors to the neighbouring
+// pages, empty when there is none
+type OrderListResponse struct {
+	Orders     []*OrderResponse
+	NextCursor string
+	PrevCursor string
 }
 
 type service struct {
-	orderRepo repository.OrderRepository
+	orderRepo  repository.OrderRepository
+	productSrv productSrv.ProductService
+	pricing    pricingSrv.PricingService
+	cursors    *cursor.Codec
 }
 
-func NewService(orderRepo repository.OrderRepository) OrderService {
-	return &service{orderRepo: orderRepo}
+func NewService(
+	orderRepo repository.OrderRepository,
+	products productSrv.ProductService,
+	pricing pricingSrv.PricingService,
+	cursors *cursor.Codec,
+) OrderService {
+	return &service{orderRepo: orderRepo, productSrv: products, pricing: pricing, cursors: cursors}
 }
 
 // CancelOrder implements OrderService.
-func (s *service) CancelOrder(ctx context.Context, id uuid.UUID) error {
-	panic("unimplemented")
+func (s *service) CancelOrder(ctx context.Context, id uuid.UUID, changedBy uuid.UUID) (*OrderResponse, error) {
+	return s.UpdateOrderStatus(ctx, id, models.OrderStatusCancelled, changedBy)
 }
 
-// CreateOrder implements OrderService.
-func (s *service) CreateOrder(ctx context.Context, req CreateOrderRequset) (*OrderResponse, error) {
-	panic("unimplemented")
+// CreateOrder implements OrderService. It converts the user's cart into a
+// pending order; stock is decremented in the same transaction.
+func (s *service) CreateOrder(ctx context.Context, userID uuid.UUID, req CreateOrderRequest) (*OrderResponse, error) {
+	if req.PaymentMethod != models.PaymentMethodCash && req.PaymentMethod != models.PaymentMethodCard {
+		return nil, ErrInvalidPaymentMethod
+	}
+	addr := req.ShippingAddress
+	if strings.TrimSpace(addr.FullName) == "" ||
+		strings.TrimSpace(addr.Street) == "" ||
+		strings.TrimSpace(addr.City) == "" ||
+		strings.TrimSpace(addr.Country) == "" {
+		return nil, ErrInvalidShippingAddress
+	}
+
+	// Курс фіксується в замовленні на момент оформлення
+	quote, err := s.pricing.Quote(ctx, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	order := &models.Order{
+		ID:              uuid.New(),
+		UserID:          userID,
+		Status:          models.OrderStatusPending,
+		Currency:        quote.Currency,
+		ExchangeRate:    quote.Rate,
+		ShippingAddress: addr,
+		PaymentMethod:   req.PaymentMethod,
+	}
+	if err := s.orderRepo.CreateFromCart(ctx, order); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrEmptyCart):
+			return nil, ErrEmptyCart
+		case errors.Is(err, repository.ErrInsufficientStock):
+			return nil, productSrv.ErrInsufficientStock
+		case errors.Is(err, repository.ErrProductUnavailable):
+			return nil, productSrv.ErrProductNotAvailable
+		}
+		return nil, fmt.Errorf("failed to create order: %w", err)
+	}
+	return &OrderResponse{Order: order}, nil
 }
 
 // GetOrder implements OrderService.
 func (s *service) GetOrder(ctx context.Context, id uuid.UUID) (*OrderResponse, error) {
-	panic("unimplemented")
+	order, err := s.orderRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	return &OrderResponse{Order: order}, nil
+}
+
+// ListOrder implements OrderService. With after set, a cursor from an
+// earlier page, the offset is ignored.
+func (s *service) ListOrder(ctx context.Context, filter *models.OrderFilter, after string) (*OrderListResponse, error) {
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	limit := filter.Limit
+
+	backward := false
+	if after != "" {
+		cur, err := s.cursors.Decode(after)
+		if err != nil || cur.Sort != orderCursorSort {
+			return nil, ErrInvalidCursor
+		}
+		backward = cur.Backward
+		filter.Cursor = &models.Keyset{Key: cur.Key, ID: cur.ID, Backward: cur.Backward}
+	}
+	// Один зайвий рядок показує, чи є ще сторінка в цьому напрямку
+	filter.Limit = limit + 1
+
+	orders, err := s.orderRepo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+	more := len(orders) > limit
+	if more && backward {
+		orders = orders[1:]
+	} else if more {
+		orders = orders[:limit]
+	}
+
+	hasNext, hasPrev := more, filter.Cursor != nil || filter.Offset > 0
+	if backward {
+		hasNext, hasPrev = true, more
+	}
+
+	response := &OrderListResponse{Orders: make([]*OrderResponse, 0, len(orders))}
+	for _, order := range orders {
+		response.Orders = append(response.Orders, &OrderResponse{Order: order})
+	}
+	if len(orders) > 0 {
+		if hasNext {
+			response.NextCursor = s.orderCursor(orders[len(orders)-1], false)
+		}
+		if hasPrev {
+			response.PrevCursor = s.orderCursor(orders[0], true)
+		}
+	}
+	return response, nil
 }
 
-// ListOrder implements OrderService.
-func (s *service) ListOrder(ctx context.Context, filter *models.OrderFilter) ([]*OrderResponse, error) {
-	panic("unimplemented")
+// orderCursorSort tags order cursors, so product cursors are rejected
+const orderCursorSort = "order_created_at_desc"
+
+func (s *service) orderCursor(order *models.Order, backward bool) string {
+	return s.cursors.Encode(cursor.Cursor{
+		Sort:     orderCursorSort,
+		Key:      order.CreatedAt.Format(time.RFC3339Nano),
+		ID:       order.ID,
+		Backward: backward,
+	})
+}
+
+// UpdateOrderStatus implements OrderService. Moving an order to cancelled
+// releases the stock reservations made at checkout, atomically with the
+// status change.
+func (s *service) UpdateOrderStatus(ctx context.Context, id uuid.UUID, status string, changedBy uuid.UUID) (*OrderResponse, error) {
+	if _, ok := transitions[status]; !ok {
+		return nil, ErrInvalidStatus
+	}
+
+	resp, err := s.GetOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	order := resp.Order
+	if !CanTransition(order.Status, status) {
+		return nil, &InvalidTransitionError{From: order.Status, To: status}
+	}
+
+	if err := s.orderRepo.UpdateStatus(ctx, id, order.Status, status, changedBy); err != nil {
+		if errors.Is(err, repository.ErrStatusChanged) {
+			// Статус змінився між читанням і записом
+			return nil, &InvalidTransitionError{From: order.Status, To: status}
+		}
+		return nil, fmt.Errorf("failed to update order status: %w", err)
+	}
+
+	return s.GetOrder(ctx, id)
 }
 
-// UpdateOrderStatus implements OrderService.
-func (s *service) UpdateOrderStatus(ctx context.Context, id uuid.UUID, status string) (*OrderResponse, error) {
-	panic("unimplemented")
+// GetStatusHistory implements OrderService.
+func (s *service) GetStatusHistory(ctx context.Context, id uuid.UUID) ([]*models.OrderStatusChange, error) {
+	if _, err := s.GetOrder(ctx, id); err != nil {
+		return nil, err
+	}
+	history, err := s.orderRepo.ListStatusHistory(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order status history: %w", err)
+	}
+	return history, nil
 }
@@ -0,0 +1,8 @@
+package policy
+
+import "github.com/nightx1x/ecommerce/interval/apperror"
+
+var (
+	// Authorization errors
+	ErrForbidden = apperror.Forbidden("forbidden", "access forbidden")
+)
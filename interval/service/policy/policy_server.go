@@ -0,0 +1,92 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	models "github.com/nightx1x/ecommerce/interval/domain"
+	repository "github.com/nightx1x/ecommerce/interval/repository/postgres"
+	userSrv "github.com/nightx1x/ecommerce/interval/service/user"
+)
+
+// Permission is a single capability a route can require, e.g. "products:write"
+type Permission string
+
+const (
+	PermAdminAccess     Permission = "admin:access"
+	PermProductsWrite   Permission = "products:write"
+	PermCategoriesWrite Permission = "categories:write"
+	PermOrdersManage    Permission = "orders:manage"
+	PermUsersManage     Permission = "users:manage"
+)
+
+// rolePermissions declares which permissions every role in users.role holds
+var rolePermissions = map[string][]Permission{
+	models.RoleAdmin: {
+		PermAdminAccess,
+		PermProductsWrite,
+		PermCategoriesWrite,
+		PermOrdersManage,
+		PermUsersManage,
+	},
+	models.RoleCustomer: {},
+}
+
+type PolicyService interface {
+	// Role returns the current role of the user as stored in the users table
+	Role(ctx context.Context, userID uuid.UUID) (string, error)
+	// Authorize returns the user's role, or ErrForbidden unless the role
+	// grants every listed permission
+	Authorize(ctx context.Context, userID uuid.UUID, perms ...Permission) (string, error)
+}
+
+type service struct {
+	userRepo repository.UserRepository
+}
+
+func NewService(userRepo repository.UserRepository) PolicyService {
+	return &service{userRepo: userRepo}
+}
+
+// HasPermission reports whether role grants perm
+func HasPermission(role string, perm Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Role implements PolicyService.
+func (s *service) Role(ctx context.Context, userID uuid.UUID) (string, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return "", userSrv.ErrUnauthorized
+		}
+		return "", fmt.Errorf("failed to get user role: %w", err)
+	}
+	if _, ok := rolePermissions[user.Role]; !ok {
+		return "", userSrv.ErrInvalidRole
+	}
+	return user.Role, nil
+}
+
+// Authorize implements PolicyService. The role is read from the database on
+// every call so that demoting a user takes effect before their access token
+// expires.
+func (s *service) Authorize(ctx context.Context, userID uuid.UUID, perms ...Permission) (string, error) {
+	role, err := s.Role(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	for _, perm := range perms {
+		if !HasPermission(role, perm) {
+			return role, ErrForbidden
+		}
+	}
+	return role, nil
+}
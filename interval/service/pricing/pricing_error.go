@@ -0,0 +1,25 @@
+package pricing
+
+import (
+	"net/http"
+
+	"github.com/nightx1x/ecommerce/interval/apperror"
+	models "github.com/nightx1x/ecommerce/interval/domain"
+	productSrv "github.com/nightx1x/ecommerce/interval/service/product"
+)
+
+var (
+	// Currency errors
+	ErrInvalidCurrency     = apperror.BadRequest("invalid_currency", "currency must be an ISO 4217 code such as EUR")
+	ErrUnsupportedCurrency = apperror.New(http.StatusNotAcceptable, "unsupported_currency", "no exchange rate for the requested currency")
+	ErrBaseCurrency        = apperror.BadRequest("base_currency", models.DefaultCurrency+" prices are set on the product itself")
+
+	// Price list errors
+	ErrProductNotFound = productSrv.ErrProductNotFound
+	ErrPriceNotFound   = apperror.NotFound("price_not_found", "product has no price in this currency")
+	ErrInvalidPrice    = productSrv.ErrInvalidPrice
+
+	// Exchange rate errors
+	ErrNoRates     = apperror.BadRequest("exchange_rates_required", "at least one exchange rate is required")
+	ErrInvalidRate = apperror.BadRequest("invalid_exchange_rate", "exchange rate must be greater than 0")
+)
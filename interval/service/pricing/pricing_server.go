@@ -0,0 +1,273 @@
+package pricing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	models "github.com/nightx1x/ecommerce/interval/domain"
+	repository "github.com/nightx1x/ecommerce/interval/repository/postgres"
+)
+
+// PricingService shows catalog prices in the customer's currency. Prices
+// are kept in models.DefaultCurrency on the product; other currencies use
+// an explicit price from the product's price list or else the current
+// exchange rate.
+type PricingService interface {
+	Quote(ctx context.Context, currency string) (Quote, error)
+	PriceProducts(ctx context.Context, quote Quote, products ...*models.Product) error
+	ListRates(ctx context.Context) ([]*models.ExchangeRate, error)
+	UploadRates(ctx context.Context, req UploadRatesRequest) ([]*models.ExchangeRate, error)
+	ListProductPrices(ctx context.Context, productID uuid.UUID) ([]*models.ProductPrice, error)
+	SetProductPrice(ctx context.Context, productID uuid.UUID, currency string, req SetPriceRequest) (*models.ProductPrice, error)
+	DeleteProductPrice(ctx context.Context, productID uuid.UUID, currency string) error
+}
+
+// Quote is the currency prices are shown in and its rate at the time the
+// quote was made. The zero value quotes models.DefaultCurrency.
+type Quote struct {
+	Currency string
+	// Rate is the price of one unit of Currency in models.DefaultCurrency
+	Rate models.Rate
+}
+
+// IsBase reports whether the quote needs no conversion
+func (q Quote) IsBase() bool {
+	return q.Currency == "" || q.Currency == models.DefaultCurrency
+}
+
+// FromBase converts an amount in models.DefaultCurrency to the quote
+// currency. Conversions round half up to the currency's minor unit.
+func (q Quote) FromBase(m models.Money) models.Money {
+	if q.IsBase() {
+		return m
+	}
+	return m.Convert(q.Currency, q.Rate, models.RoundHalfUp)
+}
+
+// ToBase converts an amount in the quote currency to models.DefaultCurrency
+func (q Quote) ToBase(m models.Money) models.Money {
+	if q.IsBase() {
+		return m
+	}
+	return m.Convert(models.DefaultCurrency, q.Rate.Inverse(), models.RoundHalfUp)
+}
+
+// UploadRatesRequest is the DTO for uploading exchange rates, e.g. the daily
+// rates of the central bank
+type UploadRatesRequest struct {
+	Rates []RateRequest `json:"rates" validate:"required,max=500"`
+}
+
+// RateRequest is one uploaded rate. EffectiveFrom defaults to now.
+type RateRequest struct {
+	Currency      string      `json:"currency" validate:"required"`
+	Rate          models.Rate `json:"rate" validate:"required"`
+	EffectiveFrom *time.Time  `json:"effective_from"`
+}
+
+// SetPriceRequest is the DTO for setting a product price in a currency.
+// Price is kept raw so that a bare amount is parsed in the currency of the
+// path, not in models.DefaultCurrency.
+type SetPriceRequest struct {
+	Price json.RawMessage `json:"price" validate:"required"`
+}
+
+type service struct {
+	priceRepo repository.PriceRepository
+	rateRepo  repository.ExchangeRateRepository
+}
+
+func NewService(priceRepo repository.PriceRepository, rateRepo repository.ExchangeRateRepository) PricingService {
+	return &service{priceRepo: priceRepo, rateRepo: rateRepo}
+}
+
+// normalizeCurrency upper-cases an ISO 4217 code and checks its format
+func normalizeCurrency(currency string) (string, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if len(currency) != 3 {
+		return "", ErrInvalidCurrency
+	}
+	for _, c := range currency {
+		if c < 'A' || c > 'Z' {
+			return "", ErrInvalidCurrency
+		}
+	}
+	return currency, nil
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+// Quote implements PricingService. An empty currency quotes
+// models.DefaultCurrency.
+func (s *service) Quote(ctx context.Context, currency string) (Quote, error) {
+	if currency == "" {
+		return Quote{Currency: models.DefaultCurrency}, nil
+	}
+	currency, err := normalizeCurrency(currency)
+	if err != nil {
+		return Quote{}, err
+	}
+	if currency == models.DefaultCurrency {
+		return Quote{Currency: currency}, nil
+	}
+
+	rate, err := s.rateRepo.Current(ctx, currency, time.Now())
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return Quote{}, ErrUnsupportedCurrency
+		}
+		return Quote{}, fmt.Errorf("failed to get exchange rate: %w", err)
+	}
+	return Quote{Currency: currency, Rate: rate.Rate}, nil
+}
+
+// PriceProducts implements PricingService. Product prices are replaced by
+// their price in the quote currency, and so are the price overrides of
+// loaded variants, which are always converted. Each product must be passed
+// once.
+func (s *service) PriceProducts(ctx context.Context, quote Quote, products ...*models.Product) error {
+	if quote.IsBase() || len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(products))
+	for _, p := range products {
+		ids = append(ids, p.ID)
+	}
+	explicit, err := s.priceRepo.ListForProducts(ctx, ids, quote.Currency)
+	if err != nil {
+		return fmt.Errorf("failed to price products: %w", err)
+	}
+	for _, p := range products {
+		if price, ok := explicit[p.ID]; ok {
+			p.Price = price
+		} else {
+			p.Price = quote.FromBase(p.Price)
+		}
+		for _, v := range p.Variants {
+			if v.Price != nil {
+				price := quote.FromBase(*v.Price)
+				v.Price = &price
+			}
+		}
+	}
+	return nil
+}
+
+// ListRates implements PricingService. Only the rate currently in effect is
+// returned for each currency.
+func (s *service) ListRates(ctx context.Context) ([]*models.ExchangeRate, error) {
+	rates, err := s.rateRepo.ListCurrent(ctx, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exchange rates: %w", err)
+	}
+	return rates, nil
+}
+
+// UploadRates implements PricingService. Either all rates are stored or
+// none.
+func (s *service) UploadRates(ctx context.Context, req UploadRatesRequest) ([]*models.ExchangeRate, error) {
+	if len(req.Rates) == 0 {
+		return nil, ErrNoRates
+	}
+
+	now := time.Now()
+	rates := make([]*models.ExchangeRate, 0, len(req.Rates))
+	for _, r := range req.Rates {
+		currency, err := normalizeCurrency(r.Currency)
+		if err != nil {
+			return nil, err
+		}
+		if currency == models.DefaultCurrency {
+			return nil, ErrBaseCurrency
+		}
+		// Без "rate" у запиті нульове значення означало б курс 1
+		if !r.Rate.IsSet() || r.Rate.Rat().Sign() <= 0 {
+			return nil, ErrInvalidRate
+		}
+		effectiveFrom := now
+		if r.EffectiveFrom != nil {
+			effectiveFrom = *r.EffectiveFrom
+		}
+		rates = append(rates, &models.ExchangeRate{
+			ID:            uuid.New(),
+			Currency:      currency,
+			Rate:          r.Rate,
+			EffectiveFrom: effectiveFrom,
+		})
+	}
+
+	if err := s.rateRepo.CreateMany(ctx, rates); err != nil {
+		return nil, fmt.Errorf("failed to upload exchange rates: %w", err)
+	}
+	return rates, nil
+}
+
+// ListProductPrices implements PricingService.
+func (s *service) ListProductPrices(ctx context.Context, productID uuid.UUID) ([]*models.ProductPrice, error) {
+	prices, err := s.priceRepo.ListByProduct(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list product prices: %w", err)
+	}
+	return prices, nil
+}
+
+// SetProductPrice implements PricingService. The amount of req.Price is
+// taken in currency; a different currency in the body is an error.
+func (s *service) SetProductPrice(ctx context.Context, productID uuid.UUID, currency string, req SetPriceRequest) (*models.ProductPrice, error) {
+	currency, err := normalizeCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	if currency == models.DefaultCurrency {
+		return nil, ErrBaseCurrency
+	}
+	price, err := models.ParseMoneyJSON(req.Price, currency)
+	if err != nil {
+		return nil, ErrInvalidPrice
+	}
+	if price.Currency != currency {
+		return nil, ErrInvalidCurrency
+	}
+	if !price.IsPositive() {
+		return nil, ErrInvalidPrice
+	}
+
+	productPrice := &models.ProductPrice{
+		ProductID: productID,
+		Currency:  currency,
+		Price:     price,
+	}
+	if err := s.priceRepo.Set(ctx, productPrice); err != nil {
+		if isForeignKeyViolation(err) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to set product price: %w", err)
+	}
+	return productPrice, nil
+}
+
+// DeleteProductPrice implements PricingService. The product falls back to
+// the converted price afterwards.
+func (s *service) DeleteProductPrice(ctx context.Context, productID uuid.UUID, currency string) error {
+	currency, err := normalizeCurrency(currency)
+	if err != nil {
+		return err
+	}
+	if err := s.priceRepo.Delete(ctx, productID, currency); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrPriceNotFound
+		}
+		return fmt.Errorf("failed to delete product price: %w", err)
+	}
+	return nil
+}
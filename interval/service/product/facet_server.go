@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	models "github.com/nightx1x/ecommerce/interval/domain"
+)
+
+// priceBucketCount is the number of price buckets aimed for; the step is
+// rounded to 1, 2 or 5 times a power of ten, so there can be a few more
+const priceBucketCount = 5
+
+// AttributeQuery is the raw filter on one attribute: any of Values, and for
+// number attributes a Min and Max. Values are parsed by the attribute type.
+type AttributeQuery struct {
+	Values []string
+	Min    string
+	Max    string
+}
+
+// Facets summarise the products matching a filter for sidebar filters.
+// The counts of each attribute ignore the filter on that attribute and
+// the price buckets ignore the price range, so selecting a value does not
+// hide its alternatives.
+type Facets struct {
+	Attributes []*AttributeFacet `json:"attributes"`
+	Price      []*PriceBucket    `json:"price"`
+}
+
+// AttributeFacet lists the values of a filterable attribute with the number
+// of matching products, most common first
+type AttributeFacet struct {
+	Code   string        `json:"code"`
+	Name   string        `json:"name"`
+	Type   string        `json:"type"`
+	Unit   *string       `json:"unit,omitempty"`
+	Values []*FacetValue `json:"values"`
+}
+
+// FacetValue is one value of an AttributeFacet. Selected reports whether
+// the current filter includes it.
+type FacetValue struct {
+	Value    any  `json:"value"`
+	Count    int  `json:"count"`
+	Selected bool `json:"selected,omitempty"`
+}
+
+// PriceBucket counts the products priced in [From, To)
+type PriceBucket struct {
+	From  models.Money `json:"from"`
+	To    models.Money `json:"to"`
+	Count int          `json:"count"`
+}
+
+// attributeFilters resolves the raw attribute queries by attribute code.
+// Unknown and non-filterable attributes and values that do not parse as
+// the attribute type are ErrInvalidAttributeFilter.
+func (s *service) attributeFilters(ctx context.Context, queries map[string]AttributeQuery) ([]models.AttributeFilter, error) {
+	if len(queries) == 0 {
+		return nil, nil
+	}
+	codes := make([]string, 0, len(queries))
+	for code := range queries {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+
+	attributes, err := s.attributeRepo.ListByCodes(ctx, codes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve attribute filters: %w", err)
+	}
+	if len(attributes) != len(codes) {
+		return nil, ErrInvalidAttributeFilter
+	}
+
+	filters := make([]models.AttributeFilter, 0, len(attributes))
+	for _, a := range attributes {
+		if !a.Filterable {
+			return nil, ErrInvalidAttributeFilter
+		}
+		f, err := parseAttributeQuery(a, queries[a.Code])
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
+
+func parseAttributeQuery(a *models.Attribute, q AttributeQuery) (models.AttributeFilter, error) {
+	f := models.AttributeFilter{AttributeID: a.ID, Type: a.Type}
+	if a.Type != models.AttributeNumber && (q.Min != "" || q.Max != "") {
+		return f, ErrInvalidAttributeFilter
+	}
+
+	for _, v := range q.Values {
+		switch a.Type {
+		case models.AttributeText:
+			f.Values = append(f.Values, strings.ToLower(v))
+		case models.AttributeBoolean:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return f, ErrInvalidAttributeFilter
+			}
+			f.Bools = append(f.Bools, b)
+		case models.AttributeNumber:
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return f, ErrInvalidAttributeFilter
+			}
+			f.Numbers = append(f.Numbers, n)
+		}
+	}
+
+	for _, bound := range []struct {
+		raw string
+		dst **float64
+	}{{q.Min, &f.Min}, {q.Max, &f.Max}} {
+		if bound.raw == "" {
+			continue
+		}
+		n, err := strconv.ParseFloat(bound.raw, 64)
+		if err != nil {
+			return f, ErrInvalidAttributeFilter
+		}
+		*bound.dst = &n
+	}
+
+	if len(f.Values) == 0 && len(f.Bools) == 0 && len(f.Numbers) == 0 && f.Min == nil && f.Max == nil {
+		return f, ErrInvalidAttributeFilter
+	}
+	return f, nil
+}
+
+// facets computes the Facets of the products matching filter
+func (s *service) facets(ctx context.Context, filter *models.ListFilter) (*Facets, error) {
+	attributes, err := s.attributeRepo.List(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list facets: %w", err)
+	}
+
+	selected := make(map[uuid.UUID]models.AttributeFilter, len(filter.Attributes))
+	for _, f := range filter.Attributes {
+		selected[f.AttributeID] = f
+	}
+
+	// Атрибути без фільтра рахуємо одним запитом, кожен вибраний — без
+	// власного фільтра
+	var unfiltered []uuid.UUID
+	for _, a := range attributes {
+		if _, ok := selected[a.ID]; !ok {
+			unfiltered = append(unfiltered, a.ID)
+		}
+	}
+	counts, err := s.productRepo.AttributeFacets(ctx, filter, unfiltered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list facets: %w", err)
+	}
+	for i, f := range filter.Attributes {
+		others := *filter
+		others.Attributes = slices.Delete(slices.Clone(filter.Attributes), i, i+1)
+		own, err := s.productRepo.AttributeFacets(ctx, &others, []uuid.UUID{f.AttributeID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list facets: %w", err)
+		}
+		counts = append(counts, own...)
+	}
+
+	byAttribute := make(map[uuid.UUID][]*FacetValue, len(attributes))
+	for _, c := range counts {
+		f, ok := selected[c.AttributeID]
+		byAttribute[c.AttributeID] = append(byAttribute[c.AttributeID], &FacetValue{
+			Value:    c.Scalar(),
+			Count:    c.Count,
+			Selected: ok && f.Matches(c.AttributeValue),
+		})
+	}
+
+	facets := &Facets{Attributes: []*AttributeFacet{}}
+	for _, a := range attributes {
+		values := byAttribute[a.ID]
+		if len(values) == 0 {
+			continue
+		}
+		facets.Attributes = append(facets.Attributes, &AttributeFacet{
+			Code:   a.Code,
+			Name:   a.Name,
+			Type:   a.Type,
+			Unit:   a.Unit,
+			Values: values,
+		})
+	}
+
+	unpriced := *filter
+	unpriced.MinPrice, unpriced.MaxPrice = nil, nil
+	if facets.Price, err = s.priceBuckets(ctx, &unpriced); err != nil {
+		return nil, err
+	}
+	return facets, nil
+}
+
+// priceBuckets splits the price range of the products matching filter into
+// about priceBucketCount buckets of a round width and counts each of them.
+// Empty buckets are left out.
+func (s *service) priceBuckets(ctx context.Context, filter *models.ListFilter) ([]*PriceBucket, error) {
+	buckets := []*PriceBucket{}
+	minPrice, maxPrice, err := s.productRepo.PriceBounds(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list price facets: %w", err)
+	}
+	if minPrice == nil || maxPrice == nil {
+		return buckets, nil
+	}
+
+	step := models.NewMoney(priceStep(minPrice.Amount, maxPrice.Amount, minPrice.Currency), minPrice.Currency)
+	histogram, err := s.productRepo.PriceHistogram(ctx, filter, step)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list price facets: %w", err)
+	}
+
+	keys := make([]int64, 0, len(histogram))
+	for n := range histogram {
+		keys = append(keys, n)
+	}
+	slices.Sort(keys)
+	for _, n := range keys {
+		buckets = append(buckets, &PriceBucket{
+			From:  models.NewMoney(n*step.Amount, step.Currency),
+			To:    models.NewMoney((n+1)*step.Amount, step.Currency),
+			Count: histogram[n],
+		})
+	}
+	return buckets, nil
+}
+
+// priceStep returns a bucket width in minor units for prices between lo and
+// hi: 1, 2 or 5 times a power of ten, at least one major unit
+func priceStep(lo, hi int64, currency string) int64 {
+	raw := (hi - lo) / priceBucketCount
+	mag := int64(1)
+	for i := 0; i < models.Exponent(currency); i++ {
+		mag *= 10
+	}
+	for mag*10 <= raw {
+		mag *= 10
+	}
+	for _, m := range []int64{1, 2, 5} {
+		if m*mag >= raw {
+			return m * mag
+		}
+	}
+	return 10 * mag
+}
@@ -1,20 +1,60 @@
 package service
 
-import "errors"
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/nightx1x/ecommerce/interval/apperror"
+	models "github.com/nightx1x/ecommerce/interval/domain"
+)
 
 // Domain errors for product service
 var (
 	// Product errors
-	ErrProductNotFound     = errors.New("product not found")
-	ErrProductNameRequired = errors.New("product name is required")
-	ErrProductNotAvailable = errors.New("product is not available")
+	ErrProductNotFound     = apperror.NotFound("product_not_found", "product not found")
+	ErrProductNameRequired = apperror.BadRequest("product_name_required", "product name is required")
+	ErrProductNotAvailable = apperror.Conflict("product_not_available", "product is not available")
 
 	// Validation errors
-	ErrInvalidPrice    = errors.New("price must be greater than 0")
-	ErrInvalidStock    = errors.New("stock must be non-negative")
-	ErrInvalidQuantity = errors.New("quantity must be greater than 0")
+	ErrInvalidPrice        = apperror.BadRequest("invalid_price", "price must be greater than 0")
+	ErrInvalidCurrency     = apperror.BadRequest("price_currency_mismatch", "prices must be given in "+models.DefaultCurrency)
+	ErrInvalidStock        = apperror.BadRequest("invalid_stock", "stock must be non-negative")
+	ErrInvalidQuantity     = apperror.BadRequest("invalid_quantity", "quantity must be greater than 0")
+	ErrInvalidCategory     = apperror.BadRequest("invalid_category", "category does not exist")
+	ErrSearchQueryRequired = apperror.BadRequest("search_query_required", "search query is required")
+	ErrInvalidCursor       = apperror.BadRequest("invalid_cursor", "cursor is invalid or does not match the sort order")
+
+	ErrInvalidAttributeFilter = apperror.BadRequest("invalid_attribute_filter", "unknown attribute or value of the wrong type in filter")
+
+	// Variant errors
+	ErrVariantNotFound = apperror.NotFound("variant_not_found", "product variant not found")
+	ErrVariantRequired = apperror.BadRequest("variant_required", "product is sold in variants, variant_id is required")
+	ErrInvalidVariant  = apperror.BadRequest("invalid_variant", "variant needs a SKU of up to 64 characters and at least one option")
+	ErrVariantExists   = apperror.Conflict("variant_exists", "a variant with this SKU or these options already exists")
+	ErrVariantInUse    = apperror.Conflict("variant_in_use", "variant is referenced by orders")
+	ErrVariantStock    = apperror.BadRequest("variant_stock", "stock of a product with variants is set per variant")
+	ErrProductHeld     = apperror.Conflict("product_held", "stock of the product is held by carts or orders; variants can be added once it is released")
 
 	// Stock errors
-	ErrInsufficientStock = errors.New("insufficient stock")
-	ErrInvalidName       = errors.New("invalid product name")
+	ErrInsufficientStock = apperror.Conflict("insufficient_stock", "insufficient stock")
+	ErrInvalidName       = apperror.BadRequest("invalid_product_name", "invalid product name")
 )
+
+// VersionConflictError is returned by UpdateProduct and UpdateVariant when
+// the product, or the variant if VariantID is set, was changed since the
+// caller read the expected version
+type VersionConflictError struct {
+	ProductID uuid.UUID
+	VariantID *uuid.UUID
+	Expected  int
+	Current   int
+}
+
+func (e *VersionConflictError) Error() string {
+	if e.VariantID != nil {
+		return fmt.Sprintf("variant %s was modified: expected version %d, current version %d",
+			*e.VariantID, e.Expected, e.Current)
+	}
+	return fmt.Sprintf("product %s was modified: expected version %d, current version %d",
+		e.ProductID, e.Expected, e.Current)
+}
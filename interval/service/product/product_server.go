@@ -2,9 +2,15 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
+	"github.com/nightx1x/ecommerce/interval/cursor"
 	models "github.com/nightx1x/ecommerce/interval/domain"
 	repository "github.com/nightx1x/ecommerce/interval/repository/postgres"
 )
@@ -12,233 +18,724 @@ import (
 type ProductService interface {
 	CreateProduct(ctx context.Context, req CreateProductRequest) (*models.Product, error)
 	GetProductByID(ctx context.Context, id uuid.UUID) (*models.Product, error)
+	GetProductIncludingArchived(ctx context.Context, id uuid.UUID) (*models.Product, error)
 	ListProducts(ctx context.Context, filter ProductFilter) (*ProductListResponse, error)
-	SearchProducts(ctx context.Context, query string, limit, offset int) ([]*models.Product, error)
+	SearchProducts(ctx context.Context, filter ProductFilter) (*SearchResponse, error)
+	SuggestProducts(ctx context.Context, prefix string, limit int) ([]*models.Suggestion, error)
 	UpdateProduct(ctx context.Context, id uuid.UUID, req UpdateProductRequest) (*models.Product, error)
-	CheckAvailability(ctx context.Context, id uuid.UUID, quantity int) (bool, error)
-	ReserveStock(ctx context.Context, id uuid.UUID, quantity int) error
-	ReleaseStock(ctx context.Context, id uuid.UUID, quantity int) error
+	PatchProduct(ctx context.Context, id uuid.UUID, patch ProductPatch) (*models.Product, error)
+	ArchiveProduct(ctx context.Context, id uuid.UUID) (*models.Product, error)
+	RestoreProduct(ctx context.Context, id uuid.UUID) (*models.Product, error)
+	CreateVariant(ctx context.Context, productID uuid.UUID, req VariantRequest) (*models.ProductVariant, error)
+	UpdateVariant(ctx context.Context, productID, variantID uuid.UUID, req VariantRequest) (*models.ProductVariant, error)
+	DeleteVariant(ctx context.Context, productID, variantID uuid.UUID) error
+	CheckAvailability(ctx context.Context, id uuid.UUID, variantID *uuid.UUID, quantity int) (bool, error)
+	ReserveStock(ctx context.Context, req ReserveStockRequest) (*models.StockReservation, error)
+	ReleaseStock(ctx context.Context, reservationID uuid.UUID) error
+	ListReservations(ctx context.Context, ownerType string, ownerID uuid.UUID) ([]*models.StockReservation, error)
+	ExpireReservations(ctx context.Context) (int64, error)
+}
+
+// ReserveStockRequest sets the quantity a cart or order holds of a product,
+// or of one of its variants. A zero TTL makes the reservation permanent
+// until it is released.
+type ReserveStockRequest struct {
+	ProductID uuid.UUID
+	VariantID *uuid.UUID
+	OwnerType string
+	OwnerID   uuid.UUID
+	Quantity  int
+	TTL       time.Duration
 }
 
 type CreateProductRequest struct {
-	Name        string     `json:"name" validate:"required,min=3,max=255"`
-	Description string     `json:"description" validate:"max=1000"`
-	Price       float64    `json:"price" validate:"required,gt=0"`
-	Stock       int        `json:"stock" validate:"required,gte=0"`
-	CategoryID  *uuid.UUID `json:"category_id" validate:"omitempty,uuid"`
-	ImageURL    string     `json:"image_url" validate:"omitempty,url"`
+	Name        string       `json:"name" validate:"required,min=3,max=255"`
+	Description string       `json:"description" validate:"max=1000"`
+	Price       models.Money `json:"price" validate:"required,gt=0"`
+	Stock       int          `json:"stock" validate:"gte=0"`
+	CategoryID  *uuid.UUID   `json:"category_id" validate:"omitempty,uuid"`
+	ImageURL    string       `json:"image_url" validate:"omitempty,url"`
+	// Variants, if any, replace Stock: the product is sold per variant
+	Variants []VariantRequest `json:"variants"`
 }
 
 // UpdateProductRequest is the DTO for updating a product
 type UpdateProductRequest struct {
-	Name        *string    `json:"name" validate:"omitempty,min=3,max=255"`
-	Description *string    `json:"description" validate:"omitempty,max=1000"`
-	Price       *float64   `json:"price" validate:"omitempty,gt=0"`
-	Stock       *int       `json:"stock" validate:"omitempty,gte=0"`
-	CategoryID  *uuid.UUID `json:"category_id" validate:"omitempty,uuid"`
-	ImageURL    *string    `json:"image_url" validate:"omitempty,url"`
+	Name        *string       `json:"name" validate:"omitempty,min=3,max=255"`
+	Description *string       `json:"description" validate:"omitempty,max=1000"`
+	Price       *models.Money `json:"price" validate:"omitempty,gt=0"`
+	Stock       *int          `json:"stock" validate:"omitempty,gte=0"`
+	CategoryID  *uuid.UUID    `json:"category_id" validate:"omitempty,uuid"`
+	ImageURL    *string       `json:"image_url" validate:"omitempty,url"`
+	// Version is the version the change is based on. When set, the update
+	// fails with VersionConflictError if the product has changed since.
+	Version *int `json:"version,omitempty"`
+}
+
+// ProductPatch is a JSON Merge Patch (RFC 7386) of a product: absent
+// members are left untouched and null clears the field.
+type ProductPatch struct {
+	Name        PatchField[string]       `json:"name" validate:"omitempty,min=3,max=255"`
+	Description PatchField[string]       `json:"description" validate:"omitempty,max=1000"`
+	Price       PatchField[models.Money] `json:"price" validate:"omitempty,gt=0"`
+	Stock       PatchField[int]          `json:"stock" validate:"omitempty,gte=0"`
+	CategoryID  PatchField[uuid.UUID]    `json:"category_id" validate:"omitempty,uuid"`
+	ImageURL    PatchField[string]       `json:"image_url" validate:"omitempty,url"`
+	// Version is the version the patch is based on, see UpdateProductRequest
+	Version *int `json:"-"`
+}
+
+// PatchField is a member of a merge patch. Set reports whether the member
+// was present; Value is nil when it was null.
+type PatchField[T any] struct {
+	Set   bool
+	Value *T
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It is only called for members
+// present in the document, including explicit nulls.
+func (f *PatchField[T]) UnmarshalJSON(data []byte) error {
+	f.Set = true
+	if string(data) == "null" {
+		f.Value = nil
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	f.Value = &v
+	return nil
+}
+
+// ValidationValue implements validation.Optional. Absent and null members
+// are both reported as missing.
+func (f PatchField[T]) ValidationValue() any {
+	return f.Value
 }
 
 // ProductFilter is the DTO for filtering products
 type ProductFilter struct {
-	CategoryID *uuid.UUID `json:"category_id"`
-	MinPrice   *float64   `json:"min_price" validate:"omitempty,gte=0"`
-	MaxPrice   *float64   `json:"max_price" validate:"omitempty,gte=0"`
-	Search     string     `json:"search"`
-	InStock    *bool      `json:"in_stock"`
-	OrderBy    string     `json:"order_by" validate:"omitempty,oneof=price_asc price_desc name_asc name_desc created_at_asc created_at_desc"`
-	Limit      int        `json:"limit" validate:"required,min=1,max=100"`
-	Offset     int        `json:"offset" validate:"gte=0"`
+	CategoryID           *uuid.UUID    `json:"category_id"`
+	IncludeSubcategories bool          `json:"include_subcategories"`
+	IncludeArchived      bool          `json:"include_archived"`
+	MinPrice             *models.Money `json:"min_price"`
+	MaxPrice             *models.Money `json:"max_price"`
+	Search               string        `json:"search"`
+	// Attributes filters by attribute code; see AttributeQuery
+	Attributes map[string]AttributeQuery `json:"attributes"`
+	InStock    *bool                     `json:"in_stock"`
+	OrderBy    string                    `json:"order_by" validate:"omitempty,oneof=price_asc price_desc name_asc name_desc created_at_asc created_at_desc"`
+	Limit      int                       `json:"limit" validate:"required,min=1,max=100"`
+	Offset     int                       `json:"offset" validate:"gte=0"`
+	// Cursor is a next_cursor or prev_cursor of an earlier page. It replaces
+	// Offset and must be used with the same OrderBy.
+	Cursor string `json:"cursor"`
 }
 
-// ProductListResponse contains paginated products and metadata
+// ProductListResponse contains paginated products and metadata. Pagination
+// and Facets are only filled for offset pages; cursor pages skip counting.
 type ProductListResponse struct {
 	Products []*models.Product `json:"products"`
-	Total    int               `json:"total"`
-	Limit    int               `json:"limit"`
-	Offset   int               `json:"offset"`
+	*Pagination
+	Facets     *Facets `json:"facets,omitempty"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+	PrevCursor string  `json:"prev_cursor,omitempty"`
+}
+
+// SearchResponse contains full text search matches, most relevant first.
+// DidYouMean is set when the query matched nothing and the results are for
+// the closest product name instead.
+type SearchResponse struct {
+	Results    []*models.ProductSearchResult `json:"results"`
+	DidYouMean string                        `json:"did_you_mean,omitempty"`
+	Pagination
+}
+
+// Pagination describes the position of an offset page in the full result
+type Pagination struct {
+	Total      int  `json:"total"`
+	Limit      int  `json:"limit"`
+	Offset     int  `json:"offset"`
+	Page       int  `json:"page"`
+	TotalPages int  `json:"total_pages"`
+	HasNext    bool `json:"has_next"`
+	HasPrev    bool `json:"has_prev"`
+}
+
+// NewPagination computes page metadata; limit must be positive
+func NewPagination(total, limit, offset int) Pagination {
+	return Pagination{
+		Total:      total,
+		Limit:      limit,
+		Offset:     offset,
+		Page:       offset/limit + 1,
+		TotalPages: (total + limit - 1) / limit,
+		HasNext:    offset+limit < total,
+		HasPrev:    offset > 0,
+	}
 }
 
 type service struct {
-	productRepo repository.ProductRepository
+	productRepo     repository.ProductRepository
+	categoryRepo    repository.CategoryRepository
+	reservationRepo repository.ReservationRepository
+	suggestionRepo  repository.SuggestionRepository
+	variantRepo     repository.VariantRepository
+	attributeRepo   repository.AttributeRepository
+	imageRepo       repository.ImageRepository
+	cursors         *cursor.Codec
 }
 
-// CheckAvailability implements ProductService.
-func (s *service) CheckAvailability(ctx context.Context, id uuid.UUID, quantity int) (bool, error) {
-	product, err := s.productRepo.GetByID(ctx, id)
+// checkCategory verifies that a referenced category exists
+func (s *service) checkCategory(ctx context.Context, id *uuid.UUID) error {
+	if id == nil {
+		return nil
+	}
+	exists, err := s.categoryRepo.Exists(ctx, *id)
 	if err != nil {
-		return false, ErrProductNotFound
+		return fmt.Errorf("failed to check category: %w", err)
 	}
-	if product.Stock >= quantity {
-		return true, nil
+	if !exists {
+		return ErrInvalidCategory
 	}
-	return product.Stock < quantity, nil
+	return nil
 }
 
-// GetProductByID implements ProductService.
+// CheckAvailability implements ProductService. variantID is required for
+// products sold in variants.
+func (s *service) CheckAvailability(ctx context.Context, id uuid.UUID, variantID *uuid.UUID, quantity int) (bool, error) {
+	product, err := s.GetProductByID(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if err := checkVariant(product, variantID); err != nil {
+		return false, err
+	}
+	if variantID != nil {
+		return product.Variant(*variantID).Stock >= quantity, nil
+	}
+	return product.Stock >= quantity, nil
+}
+
+// GetProductByID implements ProductService. Archived products are reported
+// as not found.
 func (s *service) GetProductByID(ctx context.Context, id uuid.UUID) (*models.Product, error) {
-	product, err := s.productRepo.GetByID(ctx, id)
+	product, err := s.GetProductIncludingArchived(ctx, id)
 	if err != nil {
+		return nil, err
+	}
+	if product.IsArchived() {
 		return nil, ErrProductNotFound
 	}
 	return product, nil
 }
 
+// GetProductIncludingArchived implements ProductService. The product comes
+// with its variants and their option matrix, attributes and images.
+func (s *service) GetProductIncludingArchived(ctx context.Context, id uuid.UUID) (*models.Product, error) {
+	product, err := s.productRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if err := s.loadVariants(ctx, product); err != nil {
+		return nil, err
+	}
+	if product.Attributes, err = s.attributeRepo.ListByProduct(ctx, id); err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product.Images, err = s.imageRepo.ListByProduct(ctx, id); err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	return product, nil
+}
+
+// ArchiveProduct implements ProductService. Archived products disappear from
+// the catalogue but stay referenced by existing orders.
+func (s *service) ArchiveProduct(ctx context.Context, id uuid.UUID) (*models.Product, error) {
+	if err := s.productRepo.Archive(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to archive product: %w", err)
+	}
+	return s.GetProductIncludingArchived(ctx, id)
+}
+
+// RestoreProduct implements ProductService.
+func (s *service) RestoreProduct(ctx context.Context, id uuid.UUID) (*models.Product, error) {
+	if err := s.productRepo.Restore(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to restore product: %w", err)
+	}
+	return s.GetProductIncludingArchived(ctx, id)
+}
+
 // ListProducts implements ProductService.
 func (s *service) ListProducts(ctx context.Context, filter ProductFilter) (*ProductListResponse, error) {
 	if filter.Limit <= 0 {
 		filter.Limit = 20
 	}
-	if filter.Limit >= 100 {
+	if filter.Limit > 100 {
 		filter.Limit = 100
 	}
-
-	if filter.Offset > 0 {
-		filter.Limit = 20
+	if filter.Offset < 0 {
+		filter.Offset = 0
 	}
 
-	if filter.MinPrice != nil && *filter.MinPrice < 0 {
+	if filter.MinPrice != nil && filter.MinPrice.IsNegative() {
 		return nil, ErrInvalidPrice
 	}
-	if filter.MaxPrice != nil && *filter.MaxPrice < 0 {
+	if filter.MaxPrice != nil && filter.MaxPrice.IsNegative() {
 		return nil, ErrInvalidPrice
 	}
 
+	orderBy := filter.OrderBy
+	if orderBy == "" {
+		orderBy = defaultProductOrder
+	}
+	attributes, err := s.attributeFilters(ctx, filter.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
 	repoFilter := models.ListFilter{
-		CategoryID: filter.CategoryID,
-		MinPrice:   filter.MinPrice,
-		MaxPrice:   filter.MaxPrice,
-		Search:     filter.Search,
-		OrderBy:    filter.OrderBy,
-		Limit:      filter.Limit,
-		Offset:     filter.Offset,
+		CategoryID:           filter.CategoryID,
+		IncludeSubcategories: filter.IncludeSubcategories,
+		IncludeArchived:      filter.IncludeArchived,
+		MinPrice:             filter.MinPrice,
+		MaxPrice:             filter.MaxPrice,
+		InStock:              filter.InStock,
+		Attributes:           attributes,
+		Search:               filter.Search,
+		OrderBy:              orderBy,
+		Limit:                filter.Limit,
+		Offset:               filter.Offset,
 	}
 
-	product, err := s.productRepo.List(ctx, &repoFilter)
+	if filter.Cursor == "" {
+		products, err := s.productRepo.List(ctx, &repoFilter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list products: %w", err)
+		}
+		total, err := s.productRepo.Count(ctx, &repoFilter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list products: %w", err)
+		}
+
+		facets, err := s.facets(ctx, &repoFilter)
+		if err != nil {
+			return nil, err
+		}
+
+		pagination := NewPagination(total, filter.Limit, filter.Offset)
+		page := s.productPage(products, orderBy, pagination.HasNext, pagination.HasPrev, &pagination)
+		page.Facets = facets
+		return page, nil
+	}
+
+	cur, err := s.cursors.Decode(filter.Cursor)
+	if err != nil || cur.Sort != orderBy {
+		return nil, ErrInvalidCursor
+	}
+	repoFilter.Cursor = &models.Keyset{Key: cur.Key, ID: cur.ID, Backward: cur.Backward}
+	// Один зайвий рядок показує, чи є ще сторінка в цьому напрямку
+	repoFilter.Limit = filter.Limit + 1
+
+	products, err := s.productRepo.List(ctx, &repoFilter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list products: %w", err)
 	}
+	more := len(products) > filter.Limit
+	if more && cur.Backward {
+		products = products[1:]
+	} else if more {
+		products = products[:filter.Limit]
+	}
 
-	if filter.InStock != nil {
-		filteredProducts := make([]*models.Product, 0)
-		for _, p := range product {
-			if p.Stock > 0 {
-				filteredProducts = append(filteredProducts, p)
-			}
-		}
-		product = filteredProducts
+	// The page we came from is always there in the opposite direction
+	if cur.Backward {
+		return s.productPage(products, orderBy, true, more, nil), nil
 	}
-	return &ProductListResponse{
-		Products: product,
-		Total:    len(product),
-		Limit:    filter.Limit,
-		Offset:   filter.Offset,
-	}, nil
+	return s.productPage(products, orderBy, more, true, nil), nil
 }
 
-// ReleaseStock implements ProductService.
-func (s *service) ReleaseStock(ctx context.Context, id uuid.UUID, quantity int) error {
-	product, err := s.productRepo.GetByID(ctx, id)
-	if err != nil {
-		return err
+// defaultProductOrder is the listing order when the client sets none
+const defaultProductOrder = "created_at_desc"
+
+// productPage builds the list response with cursors to the neighbouring
+// pages of products
+func (s *service) productPage(products []*models.Product, orderBy string, hasNext, hasPrev bool, pagination *Pagination) *ProductListResponse {
+	response := &ProductListResponse{Products: products, Pagination: pagination}
+	if len(products) == 0 {
+		return response
+	}
+	if hasNext {
+		response.NextCursor = s.productCursor(products[len(products)-1], orderBy, false)
+	}
+	if hasPrev {
+		response.PrevCursor = s.productCursor(products[0], orderBy, true)
 	}
+	return response
+}
 
-	product.Stock += quantity
-	return s.productRepo.Update(ctx, product)
+func (s *service) productCursor(p *models.Product, orderBy string, backward bool) string {
+	var key string
+	switch orderBy[:strings.LastIndex(orderBy, "_")] {
+	case "price":
+		key = p.Price.Decimal()
+	case "name":
+		key = p.Name
+	default:
+		key = p.CreatedAt.Format(time.RFC3339Nano)
+	}
+	return s.cursors.Encode(cursor.Cursor{Sort: orderBy, Key: key, ID: p.ID, Backward: backward})
+}
 
+// ReleaseStock implements ProductService. Releasing a reservation that was
+// already released or has expired is not an error.
+func (s *service) ReleaseStock(ctx context.Context, reservationID uuid.UUID) error {
+	if _, err := s.reservationRepo.Release(ctx, reservationID); err != nil {
+		return fmt.Errorf("failed to release stock: %w", err)
+	}
+	return nil
 }
 
-// ReserveStock implements ProductService.
-func (s *service) ReserveStock(ctx context.Context, id uuid.UUID, quantity int) error {
-	product, err := s.productRepo.GetByID(ctx, id)
+// ReserveStock implements ProductService. Calling it again for the same
+// product (or variant) and owner resizes the existing reservation. Products
+// sold in variants are reserved per variant.
+func (s *service) ReserveStock(ctx context.Context, req ReserveStockRequest) (*models.StockReservation, error) {
+	if req.Quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+	product, err := s.GetProductByID(ctx, req.ProductID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if err := checkVariant(product, req.VariantID); err != nil {
+		return nil, err
+	}
+
+	reservation := &models.StockReservation{
+		ID:        uuid.New(),
+		ProductID: req.ProductID,
+		VariantID: req.VariantID,
+		OwnerType: req.OwnerType,
+		OwnerID:   req.OwnerID,
+		Quantity:  req.Quantity,
+	}
+	if req.TTL > 0 {
+		expiresAt := time.Now().Add(req.TTL)
+		reservation.ExpiresAt = &expiresAt
 	}
-	if product.Stock < quantity {
-		return ErrInvalidStock
+
+	if err := s.reservationRepo.Hold(ctx, reservation); err != nil {
+		if errors.Is(err, repository.ErrInsufficientStock) {
+			return nil, ErrInsufficientStock
+		}
+		return nil, fmt.Errorf("failed to reserve stock: %w", err)
+	}
+	return reservation, nil
+}
+
+// ListReservations implements ProductService.
+func (s *service) ListReservations(ctx context.Context, ownerType string, ownerID uuid.UUID) ([]*models.StockReservation, error) {
+	reservations, err := s.reservationRepo.ListActiveByOwner(ctx, ownerType, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reservations: %w", err)
 	}
-	product.Stock -= quantity
-	return s.productRepo.Update(ctx, product)
+	return reservations, nil
+}
 
+// ExpireReservations implements ProductService.
+func (s *service) ExpireReservations(ctx context.Context) (int64, error) {
+	n, err := s.reservationRepo.ExpireDue(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire reservations: %w", err)
+	}
+	return n, nil
 }
 
-// SearchProducts implements ProductService.
-func (s *service) SearchProducts(ctx context.Context, query string, limit int, offset int) ([]*models.Product, error) {
-	if query == "" {
-		return nil, ErrProductNameRequired
+// SearchProducts implements ProductService. filter.Search is the query;
+// results are ordered by relevance, so OrderBy and Cursor are ignored.
+func (s *service) SearchProducts(ctx context.Context, filter ProductFilter) (*SearchResponse, error) {
+	if strings.TrimSpace(filter.Search) == "" {
+		return nil, ErrSearchQueryRequired
 	}
 
-	if limit <= 0 || limit > 100 {
-		limit = 20
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
 	}
-	if offset < 0 {
-		offset = 0
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	attributes, err := s.attributeFilters(ctx, filter.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	repoFilter := models.ListFilter{
+		CategoryID:           filter.CategoryID,
+		IncludeSubcategories: filter.IncludeSubcategories,
+		MinPrice:             filter.MinPrice,
+		MaxPrice:             filter.MaxPrice,
+		InStock:              filter.InStock,
+		Attributes:           attributes,
+		Search:               filter.Search,
+		Limit:                filter.Limit,
+		Offset:               filter.Offset,
+	}
+	response, err := s.search(ctx, &repoFilter)
+	if err != nil {
+		return nil, err
 	}
-	filter := models.ListFilter{
-		Search: query,
-		Limit:  limit,
-		Offset: offset,
+	if response.Total > 0 || filter.Offset > 0 {
+		return response, nil
+	}
+
+	// Нічого не знайдено: пробуємо найближчу назву товару
+	correction, ok, err := s.suggestionRepo.Correct(ctx, filter.Search)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search products: %w", err)
+	}
+	if !ok || strings.EqualFold(correction, filter.Search) {
+		return response, nil
+	}
+	repoFilter.Search = correction
+	corrected, err := s.search(ctx, &repoFilter)
+	if err != nil {
+		return nil, err
+	}
+	if corrected.Total == 0 {
+		return response, nil
+	}
+	corrected.DidYouMean = correction
+	return corrected, nil
+}
+
+func (s *service) search(ctx context.Context, filter *models.ListFilter) (*SearchResponse, error) {
+	results, err := s.productRepo.Search(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search products: %w", err)
 	}
-	products, err := s.productRepo.List(ctx, &filter)
+	total, err := s.productRepo.Count(ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search products: %w", err)
 	}
-	return products, nil
+	return &SearchResponse{
+		Results:    results,
+		Pagination: NewPagination(total, filter.Limit, filter.Offset),
+	}, nil
+}
+
+// SuggestProducts implements ProductService. It completes a partially
+// typed query with product and category names and tolerates typos.
+func (s *service) SuggestProducts(ctx context.Context, prefix string, limit int) ([]*models.Suggestion, error) {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return nil, ErrSearchQueryRequired
+	}
+	if limit <= 0 || limit > 20 {
+		limit = 10
+	}
+
+	suggestions, err := s.suggestionRepo.Suggest(ctx, prefix, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest products: %w", err)
+	}
+	return suggestions, nil
 }
 
-// UpdateProduct implements ProductService.
+// UpdateProduct implements ProductService. The write is conditional on the
+// version that was read, so a concurrent change is reported as a
+// VersionConflictError instead of being overwritten.
 func (s *service) UpdateProduct(ctx context.Context, id uuid.UUID, req UpdateProductRequest) (*models.Product, error) {
-	product, err := s.productRepo.GetByID(ctx, id)
+	product, expected, err := s.getForUpdate(ctx, id, req.Version)
 	if err != nil {
-		return nil, ErrProductNotFound
+		return nil, err
 	}
 	if req.Name != nil {
+		if err := validateName(*req.Name); err != nil {
+			return nil, err
+		}
 		product.Name = *req.Name
 	}
 	if req.Description != nil {
 		product.Description = req.Description
 	}
 	if req.Price != nil {
-		if *req.Price <= 0 {
-			return nil, ErrInvalidPrice
+		if err := validatePrice(*req.Price); err != nil {
+			return nil, err
 		}
 		product.Price = *req.Price
 	}
 	if req.Stock != nil {
+		if product.HasVariants() {
+			return nil, ErrVariantStock
+		}
 		if *req.Stock < 0 {
 			return nil, ErrInvalidStock
 		}
 		product.Stock = *req.Stock
 	}
 	if req.CategoryID != nil {
+		if err := s.checkCategory(ctx, req.CategoryID); err != nil {
+			return nil, err
+		}
 		product.CategoryID = req.CategoryID
 	}
 	if req.ImageURL != nil {
 		product.ImageURL = req.ImageURL
 	}
-	err = s.productRepo.Update(ctx, product)
+	return s.saveProduct(ctx, product, expected)
+}
+
+// PatchProduct implements ProductService.
+func (s *service) PatchProduct(ctx context.Context, id uuid.UUID, patch ProductPatch) (*models.Product, error) {
+	product, expected, err := s.getForUpdate(ctx, id, patch.Version)
+	if err != nil {
+		return nil, err
+	}
+	if patch.Name.Set {
+		if patch.Name.Value == nil {
+			return nil, ErrProductNameRequired
+		}
+		if err := validateName(*patch.Name.Value); err != nil {
+			return nil, err
+		}
+		product.Name = *patch.Name.Value
+	}
+	if patch.Description.Set {
+		product.Description = patch.Description.Value
+	}
+	if patch.Price.Set {
+		if patch.Price.Value == nil {
+			return nil, ErrInvalidPrice
+		}
+		if err := validatePrice(*patch.Price.Value); err != nil {
+			return nil, err
+		}
+		product.Price = *patch.Price.Value
+	}
+	if patch.Stock.Set {
+		if product.HasVariants() {
+			return nil, ErrVariantStock
+		}
+		if patch.Stock.Value == nil || *patch.Stock.Value < 0 {
+			return nil, ErrInvalidStock
+		}
+		product.Stock = *patch.Stock.Value
+	}
+	if patch.CategoryID.Set {
+		if err := s.checkCategory(ctx, patch.CategoryID.Value); err != nil {
+			return nil, err
+		}
+		product.CategoryID = patch.CategoryID.Value
+	}
+	if patch.ImageURL.Set {
+		product.ImageURL = patch.ImageURL.Value
+	}
+	return s.saveProduct(ctx, product, expected)
+}
+
+// getForUpdate loads a product and checks it against the version the caller
+// based its change on; a nil version means the one that was just read.
+func (s *service) getForUpdate(ctx context.Context, id uuid.UUID, version *int) (*models.Product, int, error) {
+	product, err := s.GetProductIncludingArchived(ctx, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	expected := product.Version
+	if version != nil {
+		expected = *version
+	}
+	if expected != product.Version {
+		return nil, 0, &VersionConflictError{ProductID: id, Expected: expected, Current: product.Version}
+	}
+	return product, expected, nil
+}
+
+// saveProduct writes product if it is still at the expected version
+func (s *service) saveProduct(ctx context.Context, product *models.Product, expected int) (*models.Product, error) {
+	err := s.productRepo.Update(ctx, product)
+	if errors.Is(err, repository.ErrVersionConflict) {
+		current, err := s.GetProductIncludingArchived(ctx, product.ID)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &VersionConflictError{ProductID: product.ID, Expected: expected, Current: current.Version}
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrProductNotFound
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to update product: %w", err)
 	}
 	return product, nil
 }
 
-func NewService(productRepo repository.ProductRepository) ProductService {
+// validateName applies the product name rules shared by create and update
+func validateName(name string) error {
+	if n := utf8.RuneCountInString(name); n < 3 || n > 255 {
+		return ErrInvalidName
+	}
+	return nil
+}
+
+// validatePrice checks that a price is positive and in the currency the
+// price columns are kept in
+func validatePrice(price models.Money) error {
+	if !price.IsPositive() {
+		return ErrInvalidPrice
+	}
+	if price.Currency != models.DefaultCurrency {
+		return ErrInvalidCurrency
+	}
+	return nil
+}
+
+func NewService(
+	productRepo repository.ProductRepository,
+	categoryRepo repository.CategoryRepository,
+	reservationRepo repository.ReservationRepository,
+	suggestionRepo repository.SuggestionRepository,
+	variantRepo repository.VariantRepository,
+	attributeRepo repository.AttributeRepository,
+	imageRepo repository.ImageRepository,
+	cursors *cursor.Codec,
+) ProductService {
 	return &service{
-		productRepo: productRepo,
+		productRepo:     productRepo,
+		categoryRepo:    categoryRepo,
+		reservationRepo: reservationRepo,
+		suggestionRepo:  suggestionRepo,
+		variantRepo:     variantRepo,
+		attributeRepo:   attributeRepo,
+		imageRepo:       imageRepo,
+		cursors:         cursors,
 	}
 }
 
 func (s *service) CreateProduct(ctx context.Context, req CreateProductRequest) (*models.Product, error) {
-	if req.Name == "" || len(req.Name) < 3 {
-		return nil, ErrInvalidName
+	if err := validateName(req.Name); err != nil {
+		return nil, err
 	}
-	if req.Price <= 0 {
-		return nil, ErrInvalidPrice
+	if err := validatePrice(req.Price); err != nil {
+		return nil, err
 	}
 	if req.Stock < 0 {
 		return nil, ErrInvalidStock
 	}
+	if len(req.Variants) > 0 && req.Stock != 0 {
+		return nil, ErrVariantStock
+	}
+	if err := s.checkCategory(ctx, req.CategoryID); err != nil {
+		return nil, err
+	}
 	var description *string
 	if req.Description != "" {
 		description = &req.Description
@@ -257,8 +754,20 @@ func (s *service) CreateProduct(ctx context.Context, req CreateProductRequest) (
 		CategoryID:  req.CategoryID,
 		ImageURL:    imageURL,
 	}
+	for _, v := range req.Variants {
+		variant, err := newVariant(product.ID, v)
+		if err != nil {
+			return nil, err
+		}
+		product.Variants = append(product.Variants, variant)
+	}
+	product.Options = models.VariantMatrix(product.Variants)
+
 	err := s.productRepo.Create(ctx, product)
 	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrVariantExists
+		}
 		return nil, fmt.Errorf("failed to create product: %w", err)
 	}
 	return product, nil
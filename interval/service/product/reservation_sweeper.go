@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RunReservationSweeper periodically returns the stock of expired
+// reservations until ctx is cancelled.
+func RunReservationSweeper(ctx context.Context, srv ProductService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := srv.ExpireReservations(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("reservation sweeper: %v", err)
+				}
+				continue
+			}
+			if n > 0 {
+				log.Printf("reservation sweeper: expired %d reservations", n)
+			}
+		}
+	}
+}
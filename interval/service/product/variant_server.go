@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	models "github.com/nightx1x/ecommerce/interval/domain"
+	repository "github.com/nightx1x/ecommerce/interval/repository/postgres"
+)
+
+const (
+	maxSKULength    = 64
+	maxOptionLength = 50
+)
+
+// VariantRequest is the DTO for creating or replacing a product variant.
+// A nil Price sells the variant at the product price.
+type VariantRequest struct {
+	SKU     string                `json:"sku" validate:"required,max=64"`
+	Options models.VariantOptions `json:"options"`
+	Price   *models.Money         `json:"price" validate:"omitempty,gt=0"`
+	Stock   int                   `json:"stock" validate:"gte=0"`
+	// Version is the variant version a replacement is based on, see
+	// UpdateProductRequest
+	Version *int `json:"version,omitempty"`
+}
+
+// newVariant validates req and builds the variant it describes. Option names
+// are trimmed and lower-cased, values trimmed.
+func newVariant(productID uuid.UUID, req VariantRequest) (*models.ProductVariant, error) {
+	sku := strings.TrimSpace(req.SKU)
+	if sku == "" || utf8.RuneCountInString(sku) > maxSKULength {
+		return nil, ErrInvalidVariant
+	}
+	if len(req.Options) == 0 {
+		return nil, ErrInvalidVariant
+	}
+	options := make(models.VariantOptions, len(req.Options))
+	for name, value := range req.Options {
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+		if name == "" || value == "" ||
+			utf8.RuneCountInString(name) > maxOptionLength || utf8.RuneCountInString(value) > maxOptionLength {
+			return nil, ErrInvalidVariant
+		}
+		options[name] = value
+	}
+	if req.Price != nil {
+		if err := validatePrice(*req.Price); err != nil {
+			return nil, err
+		}
+	}
+	if req.Stock < 0 {
+		return nil, ErrInvalidStock
+	}
+
+	return &models.ProductVariant{
+		ID:        uuid.New(),
+		ProductID: productID,
+		SKU:       sku,
+		Options:   options,
+		Price:     req.Price,
+		Stock:     req.Stock,
+	}, nil
+}
+
+// loadVariants fills product.Variants and the option matrix
+func (s *service) loadVariants(ctx context.Context, product *models.Product) error {
+	variants, err := s.variantRepo.ListByProduct(ctx, product.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load product variants: %w", err)
+	}
+	product.Variants = variants
+	product.Options = models.VariantMatrix(variants)
+	return nil
+}
+
+// checkVariant verifies that variantID names a variant of product, or that
+// product is sold without variants if it is nil
+func checkVariant(product *models.Product, variantID *uuid.UUID) error {
+	if variantID == nil {
+		if product.HasVariants() {
+			return ErrVariantRequired
+		}
+		return nil
+	}
+	if product.Variant(*variantID) == nil {
+		return ErrVariantNotFound
+	}
+	return nil
+}
+
+// getVariant loads a variant and checks that it belongs to productID
+func (s *service) getVariant(ctx context.Context, productID, variantID uuid.UUID) (*models.ProductVariant, error) {
+	variant, err := s.variantRepo.GetByID(ctx, variantID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrVariantNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product variant: %w", err)
+	}
+	if variant.ProductID != productID {
+		return nil, ErrVariantNotFound
+	}
+	return variant, nil
+}
+
+// CreateVariant implements ProductService. The first variant turns the
+// product into one sold per variant: its stock becomes the variants' total.
+func (s *service) CreateVariant(ctx context.Context, productID uuid.UUID, req VariantRequest) (*models.ProductVariant, error) {
+	if _, err := s.GetProductIncludingArchived(ctx, productID); err != nil {
+		return nil, err
+	}
+	variant, err := newVariant(productID, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.variantRepo.Create(ctx, variant); err != nil {
+		if errors.Is(err, repository.ErrProductHeld) {
+			return nil, ErrProductHeld
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrProductNotFound
+		}
+		if isUniqueViolation(err) {
+			return nil, ErrVariantExists
+		}
+		return nil, fmt.Errorf("failed to create product variant: %w", err)
+	}
+	return variant, nil
+}
+
+// UpdateVariant implements ProductService. The variant is replaced by req
+// if it is still at the version the caller read; stock held by carts and
+// orders in the meantime changes the version, so it is never overwritten.
+func (s *service) UpdateVariant(ctx context.Context, productID, variantID uuid.UUID, req VariantRequest) (*models.ProductVariant, error) {
+	existing, err := s.getVariant(ctx, productID, variantID)
+	if err != nil {
+		return nil, err
+	}
+	expected := existing.Version
+	if req.Version != nil {
+		expected = *req.Version
+	}
+	if expected != existing.Version {
+		return nil, &VersionConflictError{ProductID: productID, VariantID: &existing.ID, Expected: expected, Current: existing.Version}
+	}
+	variant, err := newVariant(productID, req)
+	if err != nil {
+		return nil, err
+	}
+	variant.ID = existing.ID
+	variant.CreatedAt = existing.CreatedAt
+	variant.Version = expected
+
+	if err := s.variantRepo.Update(ctx, variant); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			current, err := s.getVariant(ctx, productID, variantID)
+			if err != nil {
+				return nil, err
+			}
+			return nil, &VersionConflictError{ProductID: productID, VariantID: &current.ID, Expected: expected, Current: current.Version}
+		}
+		if isUniqueViolation(err) {
+			return nil, ErrVariantExists
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrVariantNotFound
+		}
+		return nil, fmt.Errorf("failed to update product variant: %w", err)
+	}
+	return variant, nil
+}
+
+// DeleteVariant implements ProductService. Variants that were ordered stay
+// for the order history and cannot be deleted. Deleting the last variant
+// leaves the product without variants and out of stock.
+func (s *service) DeleteVariant(ctx context.Context, productID, variantID uuid.UUID) error {
+	if _, err := s.getVariant(ctx, productID, variantID); err != nil {
+		return err
+	}
+	if err := s.variantRepo.Delete(ctx, variantID); err != nil {
+		if isForeignKeyViolation(err) {
+			return ErrVariantInUse
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrVariantNotFound
+		}
+		return fmt.Errorf("failed to delete product variant: %w", err)
+	}
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
@@ -1,20 +1,22 @@
 package service
 
-import "errors"
+import "github.com/nightx1x/ecommerce/interval/apperror"
 
 var (
 	//user errors
-	ErrUserNotFound         = errors.New("user not found")
-	ErrUserAlreadyExists    = errors.New("user already exists")
-	ErrUserEmailRequired    = errors.New("user email is required")
-	ErrUserPasswordRequired = errors.New("user password is required")
-	ErrUserFNameRequired    = errors.New("user first name is required")
-	ErrUserLNameRequired    = errors.New("user last name is required")
+	ErrUserNotFound         = apperror.NotFound("user_not_found", "user not found")
+	ErrUserAlreadyExists    = apperror.Conflict("user_already_exists", "user already exists")
+	ErrUserEmailRequired    = apperror.BadRequest("email_required", "user email is required")
+	ErrUserPasswordRequired = apperror.BadRequest("password_required", "user password is required")
+	ErrUserFNameRequired    = apperror.BadRequest("first_name_required", "user first name is required")
+	ErrUserLNameRequired    = apperror.BadRequest("last_name_required", "user last name is required")
 
 	//validation errors
-	ErrInvalidEmail       = errors.New("invalid email format")
-	ErrInvalidPassword    = errors.New("invalid password")
-	ErrInvalidCredentials = errors.New("invalid email or password")
-	ErrUnauthorized       = errors.New("unauthorized access")
-	ErrInvalidRole        = errors.New("invalid role")
+	ErrInvalidEmail       = apperror.BadRequest("invalid_email", "invalid email format")
+	ErrInvalidPassword    = apperror.BadRequest("invalid_password", "password must be between 8 and 72 characters")
+	ErrWrongPassword      = apperror.BadRequest("wrong_password", "current password is incorrect")
+	ErrInvalidName        = apperror.BadRequest("invalid_name", "name must be at most 100 characters")
+	ErrInvalidCredentials = apperror.Unauthorized("invalid_credentials", "invalid email or password")
+	ErrUnauthorized       = apperror.Unauthorized("unauthorized", "unauthorized access")
+	ErrInvalidRole        = apperror.Forbidden("invalid_role", "invalid role")
 )
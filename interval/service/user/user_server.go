@@ -2,20 +2,58 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	models "github.com/nightx1x/ecommerce/interval/domain"
 	repository "github.com/nightx1x/ecommerce/interval/repository/postgres"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordCost matches the cost of the hashes in scripts/seed.sql ($2a$10$...)
+const PasswordCost = bcrypt.DefaultCost
+
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 72 // bcrypt ignores everything past 72 bytes
+	maxNameLength     = 100
 )
 
 type UserService interface {
-	CreateUser(ctx context.Context, email, password string) (uuid.UUID, error)
+	CreateUser(ctx context.Context, req CreateUserRequest) (*models.User, error)
 	GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
-	UpdateUser(ctx context.Context, id uuid.UUID, email, password string) error
+	UpdateProfile(ctx context.Context, id uuid.UUID, req UpdateProfileRequest) (*models.User, error)
+	ChangePassword(ctx context.Context, id uuid.UUID, req ChangePasswordRequest) error
 	DeleteUser(ctx context.Context, id uuid.UUID) error
 }
 
+// CreateUserRequest is the DTO for creating a user
+type CreateUserRequest struct {
+	Email     string `json:"email" validate:"required,email"`
+	Password  string `json:"password" validate:"required,min=8,max=72"`
+	FirstName string `json:"first_name" validate:"required,max=100"`
+	LastName  string `json:"last_name" validate:"required,max=100"`
+}
+
+// UpdateProfileRequest is the DTO for updating profile fields
+type UpdateProfileRequest struct {
+	Email     *string `json:"email" validate:"omitempty,email"`
+	FirstName *string `json:"first_name" validate:"omitempty,max=100"`
+	LastName  *string `json:"last_name" validate:"omitempty,max=100"`
+}
+
+// ChangePasswordRequest is the DTO for changing the password
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=8,max=72"`
+}
+
 type service struct {
 	userRepo repository.UserRepository
 }
@@ -24,27 +62,205 @@ func NewService(userRepo repository.UserRepository) UserService {
 	return &service{userRepo: userRepo}
 }
 
+// HashPassword hashes a plain-text password with bcrypt
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), PasswordCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password matches the bcrypt hash
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// NormalizeEmail trims and lower-cases an email address
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func validateEmail(email string) error {
+	if email == "" {
+		return ErrUserEmailRequired
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return ErrInvalidEmail
+	}
+	return nil
+}
+
+func validatePassword(password string) error {
+	if password == "" {
+		return ErrUserPasswordRequired
+	}
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return ErrInvalidPassword
+	}
+	return nil
+}
+
+func validateName(name string, required error) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", required
+	}
+	if utf8.RuneCountInString(name) > maxNameLength {
+		return "", ErrInvalidName
+	}
+	return name, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
 // CreateUser implements UserService.
-func (s *service) CreateUser(ctx context.Context, email, password string) (uuid.UUID, error) {
-	panic("unimplemented")
+func (s *service) CreateUser(ctx context.Context, req CreateUserRequest) (*models.User, error) {
+	email := NormalizeEmail(req.Email)
+	if err := validateEmail(email); err != nil {
+		return nil, err
+	}
+	if err := validatePassword(req.Password); err != nil {
+		return nil, err
+	}
+	firstName, err := validateName(req.FirstName, ErrUserFNameRequired)
+	if err != nil {
+		return nil, err
+	}
+	lastName, err := validateName(req.LastName, ErrUserLNameRequired)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.GetUserByEmail(ctx, email); err == nil {
+		return nil, ErrUserAlreadyExists
+	} else if !errors.Is(err, ErrUserNotFound) {
+		return nil, err
+	}
+
+	hash, err := HashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		ID:           uuid.New(),
+		Email:        email,
+		PasswordHash: hash,
+		FirstName:    firstName,
+		LastName:     lastName,
+		Role:         models.RoleCustomer,
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		// Паралельна реєстрація з тим самим email
+		if isUniqueViolation(err) {
+			return nil, ErrUserAlreadyExists
+		}
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	return user, nil
 }
 
 // DeleteUser implements UserService.
 func (s *service) DeleteUser(ctx context.Context, id uuid.UUID) error {
-	panic("unimplemented")
+	if _, err := s.GetUserByID(ctx, id); err != nil {
+		return err
+	}
+	if err := s.userRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return nil
 }
 
 // GetUserByEmail implements UserService.
 func (s *service) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
-	panic("unimplemented")
+	user, err := s.userRepo.GetByEmail(ctx, NormalizeEmail(email))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
 }
 
 // GetUserByID implements UserService.
 func (s *service) GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
-	panic("unimplemented")
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
 }
 
-// UpdateUser implements UserService.
-func (s *service) UpdateUser(ctx context.Context, id uuid.UUID, email, password string) error {
-	panic("unimplemented")
+// UpdateProfile implements UserService.
+func (s *service) UpdateProfile(ctx context.Context, id uuid.UUID, req UpdateProfileRequest) (*models.User, error) {
+	user, err := s.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Email != nil {
+		email := NormalizeEmail(*req.Email)
+		if err := validateEmail(email); err != nil {
+			return nil, err
+		}
+		if email != user.Email {
+			if _, err := s.GetUserByEmail(ctx, email); err == nil {
+				return nil, ErrUserAlreadyExists
+			} else if !errors.Is(err, ErrUserNotFound) {
+				return nil, err
+			}
+			user.Email = email
+		}
+	}
+	if req.FirstName != nil {
+		if user.FirstName, err = validateName(*req.FirstName, ErrUserFNameRequired); err != nil {
+			return nil, err
+		}
+	}
+	if req.LastName != nil {
+		if user.LastName, err = validateName(*req.LastName, ErrUserLNameRequired); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrUserAlreadyExists
+		}
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	return user, nil
+}
+
+// ChangePassword implements UserService.
+func (s *service) ChangePassword(ctx context.Context, id uuid.UUID, req ChangePasswordRequest) error {
+	user, err := s.GetUserByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !CheckPassword(user.PasswordHash, req.OldPassword) {
+		return ErrWrongPassword
+	}
+	if err := validatePassword(req.NewPassword); err != nil {
+		return err
+	}
+
+	hash, err := HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+	user.PasswordHash = hash
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to change password: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Local stores objects as files under a directory. The files are served by
+// Handler, mounted at the public URL.
+type Local struct {
+	dir       string
+	publicURL string
+}
+
+// NewLocal returns a Local storage in dir whose objects are served from
+// publicURL, e.g. "/uploads"
+func NewLocal(dir, publicURL string) *Local {
+	return &Local{dir: dir, publicURL: publicURL}
+}
+
+func (l *Local) path(key string) string {
+	return filepath.Join(l.dir, filepath.FromSlash(key))
+}
+
+// Put implements Storage. The file is written to a temporary name first, so
+// readers never see a partial object.
+func (l *Local) Put(ctx context.Context, key string, body io.Reader, contentType string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	path := l.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	return nil
+}
+
+// Delete implements Storage.
+func (l *Local) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	if err := os.Remove(l.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// URL implements Storage.
+func (l *Local) URL(key string) string {
+	return joinURL(l.publicURL, key)
+}
+
+// Handler serves the stored files, without directory listings. Mount it
+// with the public URL path stripped.
+func (l *Local) Handler() http.Handler {
+	files := http.FileServer(http.Dir(l.dir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/")
+		if checkKey(key) != nil || strings.Contains(key, "/.") {
+			http.NotFound(w, r)
+			return
+		}
+		if info, err := os.Stat(l.path(key)); err != nil || info.IsDir() {
+			http.NotFound(w, r)
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
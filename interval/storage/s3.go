@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Config locates a bucket of an S3-compatible service such as AWS S3 or
+// MinIO
+type S3Config struct {
+	// Endpoint is the service URL, e.g. https://s3.eu-central-1.amazonaws.com
+	// or http://localhost:9000
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PublicURL is where objects are served from, Endpoint/Bucket if empty
+	PublicURL string
+}
+
+// S3 stores objects in a bucket with path-style requests signed with AWS
+// Signature Version 4, which all S3-compatible services accept
+type S3 struct {
+	cfg    S3Config
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewS3 returns an S3 storage for cfg
+func NewS3(cfg S3Config) *S3 {
+	if cfg.PublicURL == "" {
+		cfg.PublicURL = joinURL(cfg.Endpoint, cfg.Bucket)
+	}
+	return &S3{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 30 * time.Second},
+		now:    time.Now,
+	}
+}
+
+// Put implements Storage.
+func (s *S3) Put(ctx context.Context, key string, body io.Reader, contentType string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return s.do(ctx, http.MethodPut, key, data, contentType)
+}
+
+// Delete implements Storage.
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	return s.do(ctx, http.MethodDelete, key, nil, "")
+}
+
+// URL implements Storage.
+func (s *S3) URL(key string) string {
+	return joinURL(s.cfg.PublicURL, key)
+}
+
+func (s *S3) do(ctx context.Context, method, key string, data []byte, contentType string) error {
+	endpoint, err := url.Parse(s.cfg.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+	endpoint.Path = "/" + s.cfg.Bucket + "/" + key
+	endpoint.RawPath = escapePath(endpoint.Path)
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to build S3 request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req, data)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("S3 %s %s: %w", method, key, err)
+	}
+	defer resp.Body.Close()
+
+	// DELETE of a missing object is 204 on S3, some stand-ins answer 404
+	if resp.StatusCode/100 == 2 || (method == http.MethodDelete && resp.StatusCode == http.StatusNotFound) {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("S3 %s %s: %s: %s", method, key, resp.Status, bytes.TrimSpace(msg))
+}
+
+// sign adds the AWS Signature Version 4 headers for a request with payload
+// data
+func (s *S3) sign(req *http.Request, data []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(data)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + s.cfg.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), day)
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+// escapePath URI-encodes every path segment as SigV4 expects: all bytes
+// but letters, digits and -_.~ are percent-encoded, slashes are kept
+func escapePath(path string) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&15])
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
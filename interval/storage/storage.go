@@ -0,0 +1,43 @@
+// Package storage keeps uploaded files, such as product images, in a local
+// directory or an S3-compatible bucket.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrInvalidKey is returned for keys that are empty, absolute or escape the
+// storage root
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// Storage stores objects under slash-separated keys such as
+// "products/<id>/<image>.jpg"
+type Storage interface {
+	// Put stores body under key, replacing an existing object
+	Put(ctx context.Context, key string, body io.Reader, contentType string) error
+	// Delete removes the object; a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL the object is served from
+	URL(key string) string
+}
+
+// checkKey rejects keys that could address files outside the storage root
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
+
+// joinURL appends key to a base URL
+func joinURL(base, key string) string {
+	return strings.TrimSuffix(base, "/") + "/" + key
+}
@@ -0,0 +1,311 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// FieldError describes a single failed rule. Field is the JSON path of the
+// value, e.g. "shipping_address.city".
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Error is returned by Validate and lists every failed field at once
+type Error struct {
+	Fields []FieldError
+}
+
+func (e *Error) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Field+": "+f.Message)
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Optional is implemented by wrapper types, such as merge patch fields,
+// whose wrapped value should be validated instead. A nil result is treated
+// like a nil pointer.
+type Optional interface {
+	ValidationValue() any
+}
+
+// Validate checks v, a struct or pointer to struct, against the rules in
+// its `validate` tags and returns *Error if any of them fail.
+//
+// Supported rules: required, omitempty, min, max, gt, gte, lt, lte, oneof,
+// email, url and uuid. min and max compare the length of strings and
+// slices and the value of numbers. Nested structs and the struct elements
+// of slices are validated as well.
+// An unknown rule is a programming error and panics.
+func Validate(v any) error {
+	var errs []FieldError
+	validateStruct(reflect.ValueOf(v), "", &errs)
+	if len(errs) > 0 {
+		return &Error{Fields: errs}
+	}
+	return nil
+}
+
+// Numeric is implemented by value types, such as money amounts, that the
+// ordering rules compare by value
+type Numeric interface {
+	ValidationNumber() float64
+}
+
+var (
+	numericType     = reflect.TypeOf((*Numeric)(nil)).Elem()
+	optionalType    = reflect.TypeOf((*Optional)(nil)).Elem()
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+func validateStruct(v reflect.Value, prefix string, errs *[]FieldError) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := jsonName(field)
+		if name == "-" {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		validateField(v.Field(i), name, field.Tag.Get("validate"), errs)
+	}
+}
+
+func validateField(v reflect.Value, name, tag string, errs *[]FieldError) {
+	if v.Type().Implements(optionalType) {
+		inner := v.Interface().(Optional).ValidationValue()
+		if inner == nil {
+			v = reflect.Value{}
+		} else {
+			v = reflect.ValueOf(inner)
+		}
+	}
+
+	rules := splitRules(tag)
+	omitEmpty := false
+	for _, r := range rules {
+		if r.name == "omitempty" {
+			omitEmpty = true
+		}
+	}
+
+	// A nil pointer means the value was not sent. Like in
+	// go-playground/validator, omitempty skips only missing values for
+	// pointers; a pointer to a zero value is still checked.
+	present := v.IsValid()
+	viaPointer := false
+	for present && v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			present = false
+			break
+		}
+		v = v.Elem()
+		viaPointer = true
+	}
+
+	for _, r := range rules {
+		switch r.name {
+		case "omitempty":
+			continue
+		case "required":
+			if !present || v.IsZero() {
+				*errs = append(*errs, FieldError{Field: name, Rule: r.name, Message: "is required"})
+				return
+			}
+			continue
+		}
+		if !present || (omitEmpty && !viaPointer && v.IsZero()) {
+			return
+		}
+		if msg, ok := check(v, r); !ok {
+			*errs = append(*errs, FieldError{Field: name, Rule: r.name, Message: msg})
+		}
+	}
+
+	if !present {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		if !isScalarStruct(v.Type()) {
+			validateStruct(v, name, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		validateElems(v, name, errs)
+	}
+}
+
+// validateElems validates the struct elements of a slice, e.g. the variants
+// of a new product, with the index in the path: "variants[1].sku"
+func validateElems(v reflect.Value, name string, errs *[]FieldError) {
+	elem := v.Type().Elem()
+	for elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct || isScalarStruct(elem) {
+		return
+	}
+	for i := 0; i < v.Len(); i++ {
+		validateStruct(v.Index(i), fmt.Sprintf("%s[%d]", name, i), errs)
+	}
+}
+
+type rule struct {
+	name  string
+	param string
+}
+
+func splitRules(tag string) []rule {
+	if tag == "" {
+		return nil
+	}
+	parts := strings.Split(tag, ",")
+	rules := make([]rule, 0, len(parts))
+	for _, p := range parts {
+		name, param, _ := strings.Cut(p, "=")
+		rules = append(rules, rule{name: name, param: param})
+	}
+	return rules
+}
+
+func check(v reflect.Value, r rule) (string, bool) {
+	switch r.name {
+	case "min", "max", "gt", "gte", "lt", "lte":
+		return compare(v, r)
+	case "oneof":
+		allowed := strings.Fields(r.param)
+		s := fmt.Sprint(v.Interface())
+		for _, a := range allowed {
+			if s == a {
+				return "", true
+			}
+		}
+		return "must be one of: " + strings.Join(allowed, ", "), false
+	case "email":
+		s, _ := v.Interface().(string)
+		addr, err := mail.ParseAddress(s)
+		if err != nil || addr.Address != s {
+			return "must be a valid email address", false
+		}
+		return "", true
+	case "url":
+		s, _ := v.Interface().(string)
+		u, err := url.ParseRequestURI(s)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return "must be a valid URL", false
+		}
+		return "", true
+	case "uuid":
+		switch id := v.Interface().(type) {
+		case uuid.UUID:
+			return "", true
+		case string:
+			if _, err := uuid.Parse(id); err == nil {
+				return "", true
+			}
+		}
+		return "must be a valid UUID", false
+	}
+	panic(fmt.Sprintf("validation: unknown rule %q", r.name))
+}
+
+// compare handles the ordering rules. Strings and slices are compared by
+// length, numbers and Numeric values by value.
+func compare(v reflect.Value, r rule) (string, bool) {
+	limit, err := strconv.ParseFloat(r.param, 64)
+	if err != nil {
+		panic(fmt.Sprintf("validation: rule %s needs a numeric parameter, got %q", r.name, r.param))
+	}
+
+	var n float64
+	unit := ""
+	switch {
+	case v.Type().Implements(numericType):
+		n = v.Interface().(Numeric).ValidationNumber()
+	case v.Kind() == reflect.String:
+		n = float64(utf8.RuneCountInString(v.String()))
+		unit = " characters"
+	case v.Kind() == reflect.Slice, v.Kind() == reflect.Map, v.Kind() == reflect.Array:
+		n = float64(v.Len())
+		unit = " items"
+	case v.CanInt():
+		n = float64(v.Int())
+	case v.CanUint():
+		n = float64(v.Uint())
+	case v.CanFloat():
+		n = v.Float()
+	default:
+		panic(fmt.Sprintf("validation: rule %s is not supported for %s", r.name, v.Type()))
+	}
+
+	switch r.name {
+	case "min":
+		if n < limit {
+			return fmt.Sprintf("must be at least %s%s", r.param, unit), false
+		}
+	case "max":
+		if n > limit {
+			return fmt.Sprintf("must be at most %s%s", r.param, unit), false
+		}
+	case "gt":
+		if n <= limit {
+			return "must be greater than " + r.param, false
+		}
+	case "gte":
+		if n < limit {
+			return "must be greater than or equal to " + r.param, false
+		}
+	case "lt":
+		if n >= limit {
+			return "must be less than " + r.param, false
+		}
+	case "lte":
+		if n > limit {
+			return "must be less than or equal to " + r.param, false
+		}
+	}
+	return "", true
+}
+
+// isScalarStruct reports struct types that are values rather than nested
+// objects and must not be descended into. Types with their own JSON
+// encoding, such as money amounts, are values too.
+func isScalarStruct(t reflect.Type) bool {
+	return t.PkgPath() == "time" || t.Implements(optionalType) ||
+		reflect.PointerTo(t).Implements(unmarshalerType)
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,134 @@
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// cents is a Numeric stand-in for money amounts
+type cents int64
+
+func (c cents) ValidationNumber() float64 {
+	return float64(c) / 100
+}
+
+type address struct {
+	City    string `json:"city" validate:"required,max=5"`
+	Country string `json:"country" validate:"required,oneof=UA PL"`
+}
+
+type line struct {
+	SKU      string `json:"sku" validate:"required,max=4"`
+	Quantity int    `json:"quantity" validate:"gt=0"`
+}
+
+type request struct {
+	Name    string   `json:"name" validate:"required,min=3,max=5"`
+	Email   string   `json:"email" validate:"omitempty,email"`
+	Price   cents    `json:"price" validate:"omitempty,gt=0"`
+	Stock   *int     `json:"stock" validate:"omitempty,gte=0"`
+	Tags    []string `json:"tags" validate:"max=2"`
+	Address *address `json:"address"`
+	Lines   []line   `json:"lines"`
+	Extra   []*line  `json:"extra"`
+	Ignored string   `json:"-" validate:"required"`
+}
+
+// fields returns the failed fields of err as "field:rule"
+func fields(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var verr *Error
+	if !errors.As(err, &verr) {
+		t.Fatalf("Validate error = %T, want *Error", err)
+	}
+	got := make([]string, 0, len(verr.Fields))
+	for _, f := range verr.Fields {
+		got = append(got, f.Field+":"+f.Rule)
+	}
+	return got
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		req  request
+		want []string
+	}{
+		{name: "valid", req: request{Name: "abc"}},
+		{name: "missing required", req: request{}, want: []string{"name:required"}},
+
+		// Lengths are counted in characters, not bytes
+		{name: "cyrillic at min", req: request{Name: "абв"}},
+		{name: "cyrillic at max", req: request{Name: "абвгд"}},
+		{name: "cyrillic below min", req: request{Name: "аб"}, want: []string{"name:min"}},
+		{name: "cyrillic above max", req: request{Name: "абвгде"}, want: []string{"name:max"}},
+
+		{name: "bad email", req: request{Name: "abc", Email: "not an email"}, want: []string{"email:email"}},
+		{name: "numeric value", req: request{Name: "abc", Price: 1}},
+		{name: "numeric not positive", req: request{Name: "abc", Price: -1}, want: []string{"price:gt"}},
+
+		// omitempty skips a missing pointer, not a pointer to zero
+		{name: "pointer to zero", req: request{Name: "abc", Stock: intPtr(0)}},
+		{name: "pointer to negative", req: request{Name: "abc", Stock: intPtr(-1)}, want: []string{"stock:gte"}},
+
+		{name: "slice length", req: request{Name: "abc", Tags: []string{"a", "b", "c"}}, want: []string{"tags:max"}},
+		{
+			name: "nested struct",
+			req:  request{Name: "abc", Address: &address{City: "Київ", Country: "DE"}},
+			want: []string{"address.country:oneof"},
+		},
+		{
+			name: "slice elements",
+			req: request{Name: "abc", Lines: []line{
+				{SKU: "A1", Quantity: 1},
+				{SKU: "", Quantity: 0},
+				{SKU: "ЖЖЖЖЖ", Quantity: 2},
+			}},
+			want: []string{"lines[1].sku:required", "lines[1].quantity:gt", "lines[2].sku:max"},
+		},
+		{
+			name: "pointer slice elements",
+			req:  request{Name: "abc", Extra: []*line{nil, {SKU: "A1", Quantity: -1}}},
+			want: []string{"extra[1].quantity:gt"},
+		},
+	}
+	for _, tt := range tests {
+		got := fields(t, Validate(&tt.req))
+		if len(got) == 0 && len(tt.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: failed fields = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateMessages(t *testing.T) {
+	err := Validate(request{Name: "абвгде"})
+	var verr *Error
+	if !errors.As(err, &verr) || len(verr.Fields) != 1 {
+		t.Fatalf("Validate = %v, want one field error", err)
+	}
+	if got, want := verr.Fields[0].Message, "must be at most 5 characters"; got != want {
+		t.Errorf("message = %q, want %q", got, want)
+	}
+}
+
+func TestValidateUnknownRule(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Validate with an unknown rule did not panic")
+		}
+	}()
+	_ = Validate(struct {
+		Name string `validate:"shiny"`
+	}{Name: "x"})
+}
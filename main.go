@@ -2,103 +2,216 @@ package main
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"log"
-	"os"
-
-	"github.com/joho/godotenv"
+	"net/http"
+	"net/url"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/nightx1x/ecommerce/interval/config"
+	"github.com/nightx1x/ecommerce/interval/cursor"
 	database "github.com/nightx1x/ecommerce/interval/db"
-	models "github.com/nightx1x/ecommerce/interval/domain"
+	handler "github.com/nightx1x/ecommerce/interval/handler/http"
 	repository "github.com/nightx1x/ecommerce/interval/repository/postgres"
-	services "github.com/nightx1x/ecommerce/interval/service/product"
+	attributeSrv "github.com/nightx1x/ecommerce/interval/service/attribute"
+	authSrv "github.com/nightx1x/ecommerce/interval/service/auth"
+	cartSrv "github.com/nightx1x/ecommerce/interval/service/cart"
+	categorySrv "github.com/nightx1x/ecommerce/interval/service/category"
+	imageSrv "github.com/nightx1x/ecommerce/interval/service/image"
+	orderSrv "github.com/nightx1x/ecommerce/interval/service/order"
+	policySrv "github.com/nightx1x/ecommerce/interval/service/policy"
+	pricingSrv "github.com/nightx1x/ecommerce/interval/service/pricing"
+	productSrv "github.com/nightx1x/ecommerce/interval/service/product"
+	userSrv "github.com/nightx1x/ecommerce/interval/service/user"
+	"github.com/nightx1x/ecommerce/interval/storage"
 )
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
-
-// isRunningInDocker перевіряє, чи запущено застосунок у Docker контейнері
-func isRunningInDocker() bool {
-	_, err := os.Stat("/.dockerenv")
-	return err == nil
-}
+const (
+	readTimeout     = 10 * time.Second
+	writeTimeout    = 15 * time.Second
+	idleTimeout     = 60 * time.Second
+	shutdownTimeout = 20 * time.Second
 
-// loadDBConfig формує конфігурацію підключення до БД
-func loadDBConfig() database.Config {
-	_ = godotenv.Load() // ігноруємо помилку — для Docker це нормально
+	reservationSweepInterval = time.Minute
+)
 
-	dbHost := getEnv("DB_HOST", "localhost")
+// app містить роутер і сервіси, яким потрібні фонові задачі
+type app struct {
+	router   chi.Router
+	products productSrv.ProductService
+}
 
-	// Якщо Docker відсутній, а DB_HOST вказує на "db" — підміняємо на localhost
-	if dbHost == "db" && !isRunningInDocker() {
-		dbHost = "localhost"
+// newApp збирає залежності та chi роутер з усіма HTTP обробниками
+func newApp(cfg *config.Config, db *database.DB) *app {
+	// Репозиторії
+	productRepo := repository.NewProductRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	reservationRepo := repository.NewReservationRepository(db)
+	suggestionRepo := repository.NewSuggestionRepository(db)
+	variantRepo := repository.NewVariantRepository(db)
+	attributeRepo := repository.NewAttributeRepository(db)
+	imageRepo := repository.NewImageRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	cartRepo := repository.NewCartRepository(db)
+	orderRepo := repository.NewOrderRepository(db)
+	priceRepo := repository.NewPriceRepository(db)
+	exchangeRateRepo := repository.NewExchangeRateRepository(db)
+
+	// Курсори пагінації підписуються ключем, похідним від JWT секрету
+	cursors := cursor.NewCodec(cfg.JWT.Secret)
+
+	files, local := newStorage(cfg.Storage)
+
+	// Сервіси
+	pricing := pricingSrv.NewService(priceRepo, exchangeRateRepo)
+	products := productSrv.NewService(productRepo, categoryRepo, reservationRepo, suggestionRepo, variantRepo, attributeRepo, imageRepo, cursors)
+	categories := categorySrv.NewService(categoryRepo)
+	attributes := attributeSrv.NewService(attributeRepo, products)
+	images := imageSrv.NewService(imageRepo, products, files, cfg.Storage.MaxUploadSize)
+	users := userSrv.NewService(userRepo)
+	auth := authSrv.NewService(users, refreshTokenRepo, authSrv.Config{
+		Secret:     cfg.JWT.Secret,
+		Issuer:     cfg.App.Name,
+		AccessTTL:  cfg.JWT.Expiration,
+		RefreshTTL: cfg.JWT.RefreshExpiration,
+	})
+	policy := policySrv.NewService(userRepo)
+	carts := cartSrv.NewService(cartRepo, products, pricing)
+	orders := orderSrv.NewService(orderRepo, products, pricing, cursors)
+
+	// Обробники
+	guard := handler.NewGuard(auth, policy)
+	productHandler := handler.NewProductHandler(products, pricing, guard)
+	categoryHandler := handler.NewCategoryHandler(categories, guard)
+	authHandler := handler.NewAuthHandler(auth)
+	userHandler := handler.NewUserHandler(users, guard)
+	cartHandler := handler.NewCartHandler(carts, guard)
+	orderHandler := handler.NewOrderHandler(orders, guard)
+	pricingHandler := handler.NewPricingHandler(pricing, guard)
+	attributeHandler := handler.NewAttributeHandler(attributes, guard)
+	imageHandler := handler.NewImageHandler(images, guard, cfg.Storage.MaxUploadSize)
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(middleware.RealIP)
+	r.Use(middleware.Logger)
+	r.Use(middleware.Recoverer)
+	r.NotFound(handler.NotFound)
+	r.MethodNotAllowed(handler.MethodNotAllowed)
+
+	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Локальне сховище віддає файли сам застосунок за STORAGE_PUBLIC_URL
+	if local != nil {
+		if u, err := url.Parse(cfg.Storage.PublicURL); err == nil && u.Path != "" && u.Path != "/" {
+			r.Handle(u.Path+"/*", http.StripPrefix(u.Path, local.Handler()))
+		}
 	}
 
-	return database.Config{
-		Host:     dbHost,
-		Port:     getEnv("DB_PORT", "5432"),
-		User:     getEnv("DB_USER", ""),
-		Password: getEnv("DB_PASSWORD", ""),
-		DBName:   getEnv("DB_NAME", "ecommerce_db"),
-		SSLMode:  getEnv("DB_SSLMODE", "disable"),
+	authHandler.RegisterRoutes(r)
+	userHandler.RegisterRoutes(r)
+	productHandler.RegisterRoutes(r)
+	categoryHandler.RegisterRoutes(r)
+	cartHandler.RegisterRoutes(r)
+	orderHandler.RegisterRoutes(r)
+	pricingHandler.RegisterRoutes(r)
+	attributeHandler.RegisterRoutes(r)
+	imageHandler.RegisterRoutes(r)
+
+	return &app{router: r, products: products}
+}
+
+// newStorage створює сховище файлів за STORAGE_DRIVER. Для локального
+// драйвера повертає також *storage.Local, щоб змонтувати його обробник.
+func newStorage(cfg config.StorageConfig) (storage.Storage, *storage.Local) {
+	if cfg.Driver == config.StorageS3 {
+		log.Printf("🗄️  Сховище файлів: S3 %s/%s", cfg.S3Endpoint, cfg.S3Bucket)
+		return storage.NewS3(storage.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PublicURL: cfg.PublicURL,
+		}), nil
 	}
+	log.Printf("🗄️  Сховище файлів: %s", cfg.LocalDir)
+	local := storage.NewLocal(cfg.LocalDir, cfg.PublicURL)
+	return local, local
 }
+
 func main() {
 	log.Println("🚀 Запуск сервісу ecommerce-api...")
 
-	dbConfig := loadDBConfig()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("❌ Помилка конфігурації: %v", err)
+	}
+	log.Printf("⚙️  Середовище: %s", cfg.App.Env)
 	log.Printf("🔌 Підключення до бази: %s@%s:%s/%s",
-		dbConfig.User, dbConfig.Host, dbConfig.Port, dbConfig.DBName)
+		cfg.DB.User, cfg.DB.Host, cfg.DB.Port, cfg.DB.DBName)
 
 	// Ініціалізація підключення
-	db, err := database.NewDB(dbConfig)
+	db, err := database.NewDB(cfg.DB)
 	if err != nil {
 		log.Fatalf("❌ Помилка підключення до БД: %v", err)
 	}
-	defer db.Close()
 
 	log.Println("✅ З'єднання з базою даних встановлено!")
 
-	// Репозиторій продуктів
-	productRepo := repository.NewProductRepository(db)
+	application := newApp(cfg, db)
 
-	productSrv := services.NewService(productRepo)
+	srv := &http.Server{
+		Addr:         cfg.Addr(),
+		Handler:      application.router,
+		ReadTimeout:  readTimeout,
+		WriteTimeout: writeTimeout,
+		IdleTimeout:  idleTimeout,
+	}
 
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	// Виведення списку товарів
-	log.Println("📦 Отримання списку товарів...")
+	// Повертаємо на склад прострочені резерви кошиків
+	go productSrv.RunReservationSweeper(ctx, application.products, reservationSweepInterval)
 
-	filter := models.ListFilter{Limit: 100}
-	products, err := productRepo.List(ctx, &filter)
-	if err != nil {
-		log.Fatalf("❌ Помилка отримання товарів: %v", err)
-	}
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("🌐 HTTP сервер слухає на %s", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
 
-	if len(products) == 0 {
-		log.Println("⚠️  База даних порожня — товарів не знайдено.")
-		return
+	select {
+	case err := <-serverErr:
+		if err != nil {
+			log.Printf("❌ Помилка HTTP сервера: %v", err)
+		}
+	case <-ctx.Done():
+		log.Println("🛑 Отримано сигнал завершення, зупиняємо сервер...")
 	}
+	stop()
 
-	fmt.Printf("\n✅ Знайдено товарів: %d\n", len(products))
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	// Даємо запитам, що виконуються, завершитися
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
 
-	for i, p := range products {
-		fmt.Printf("[%02d] 🆔 %s\n", i+1, p.ID)
-		fmt.Printf("     📦 Назва: %s\n", p.Name)
-		fmt.Printf("     💰 Ціна: %.2f грн\n", p.Price)
-		fmt.Printf("     📊 На складі: %d шт.\n", p.Stock)
-		if p.Description != nil && *p.Description != "" {
-			fmt.Printf("     📝 Опис: %s\n", *p.Description)
-		}
-		if p.CategoryID != nil {
-			fmt.Printf("     🏷  Категорія ID: %s\n", *p.CategoryID)
-		}
-		fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("⚠️  Сервер не зупинився коректно: %v", err)
+	}
+
+	if err := db.Close(); err != nil {
+		log.Printf("⚠️  Помилка закриття з'єднання з БД: %v", err)
 	}
 
-	log.Println("✨ Програма завершена успішно!")
+	log.Println("✨ Сервіс зупинено")
 }
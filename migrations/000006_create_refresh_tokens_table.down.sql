@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_refresh_tokens_user;
+DROP TABLE IF EXISTS refresh_tokens;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    from_status VARCHAR(20)
+        CHECK (from_status IN ('pending', 'confirmed', 'shipped', 'delivered', 'cancelled')),
+    to_status VARCHAR(20) NOT NULL
+        CHECK (to_status IN ('pending', 'confirmed', 'shipped', 'delivered', 'cancelled')),
+    changed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    changed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_order_status_history_order ON order_status_history(order_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_categories_parent;
+ALTER TABLE categories
+    DROP CONSTRAINT IF EXISTS categories_parent_not_self,
+    DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,5 @@
+ALTER TABLE categories
+    ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES categories(id) ON DELETE SET NULL,
+    ADD CONSTRAINT categories_parent_not_self CHECK (parent_id <> id);
+
+CREATE INDEX idx_categories_parent ON categories(parent_id);
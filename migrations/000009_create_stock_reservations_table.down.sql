@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_stock_reservations_expires;
+DROP INDEX IF EXISTS idx_stock_reservations_owner;
+DROP INDEX IF EXISTS idx_stock_reservations_active;
+DROP TABLE IF EXISTS stock_reservations;
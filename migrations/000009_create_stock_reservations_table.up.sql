@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    owner_type VARCHAR(20) NOT NULL
+        CHECK (owner_type IN ('cart', 'order')),
+    owner_id UUID NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'released', 'expired')),
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    released_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Не більше одного активного резерву на товар для одного власника
+CREATE UNIQUE INDEX idx_stock_reservations_active
+    ON stock_reservations(product_id, owner_type, owner_id)
+    WHERE status = 'active';
+CREATE INDEX idx_stock_reservations_owner ON stock_reservations(owner_type, owner_id);
+CREATE INDEX idx_stock_reservations_expires
+    ON stock_reservations(expires_at)
+    WHERE status = 'active' AND expires_at IS NOT NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_products_deleted_at ON products(deleted_at);
@@ -0,0 +1,5 @@
+CREATE INDEX idx_products_price_id ON products(price, id);
+CREATE INDEX idx_products_name_id ON products(name, id);
+CREATE INDEX idx_products_created_at_id ON products(created_at, id);
+
+CREATE INDEX idx_orders_user_created_at_id ON orders(user_id, created_at, id);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_products_search_vector;
+
+DROP TRIGGER IF EXISTS trg_products_search_vector ON products;
+DROP FUNCTION IF EXISTS products_search_vector_update();
+
+ALTER TABLE products
+    DROP COLUMN IF EXISTS search_vector;
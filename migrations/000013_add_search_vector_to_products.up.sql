@@ -0,0 +1,23 @@
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS search_vector TSVECTOR;
+
+-- Name matches weigh more than description matches in ts_rank
+CREATE OR REPLACE FUNCTION products_search_vector_update() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('english', coalesce(NEW.name, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(NEW.description, '')), 'B');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_products_search_vector
+    BEFORE INSERT OR UPDATE OF name, description ON products
+    FOR EACH ROW EXECUTE FUNCTION products_search_vector_update();
+
+UPDATE products
+SET search_vector =
+    setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(description, '')), 'B');
+
+CREATE INDEX idx_products_search_vector ON products USING GIN(search_vector);
@@ -0,0 +1,4 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_products_name_trgm ON products USING GIN(name gin_trgm_ops);
+CREATE INDEX idx_categories_name_trgm ON categories USING GIN(name gin_trgm_ops);
@@ -0,0 +1,10 @@
+ALTER TABLE order_items ALTER COLUMN price TYPE DECIMAL(10, 2);
+ALTER TABLE orders ALTER COLUMN total_amount TYPE DECIMAL(10, 2);
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS exchange_rate,
+    DROP COLUMN IF EXISTS currency;
+
+DROP INDEX IF EXISTS idx_exchange_rates_currency;
+DROP TABLE IF EXISTS exchange_rates;
+DROP TABLE IF EXISTS product_prices;
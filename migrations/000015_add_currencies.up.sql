@@ -0,0 +1,30 @@
+-- Explicit prices of a product in currencies other than UAH. Without one the
+-- UAH price in products.price is converted at the current exchange rate.
+CREATE TABLE IF NOT EXISTS product_prices (
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    currency CHAR(3) NOT NULL CHECK (currency <> 'UAH'),
+    price NUMERIC(12, 3) NOT NULL CHECK (price > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (product_id, currency)
+);
+
+-- rate is the price of one unit of currency in UAH from effective_from on
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    currency CHAR(3) NOT NULL CHECK (currency <> 'UAH'),
+    rate NUMERIC(18, 8) NOT NULL CHECK (rate > 0),
+    effective_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (currency, effective_from)
+);
+
+CREATE INDEX idx_exchange_rates_currency ON exchange_rates(currency, effective_from DESC);
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'UAH',
+    ADD COLUMN IF NOT EXISTS exchange_rate NUMERIC(18, 8) NOT NULL DEFAULT 1;
+
+-- Amounts in the order currency need room for 3-decimal currencies (BHD, KWD)
+ALTER TABLE orders ALTER COLUMN total_amount TYPE NUMERIC(12, 3);
+ALTER TABLE order_items ALTER COLUMN price TYPE NUMERIC(12, 3);
@@ -0,0 +1,23 @@
+ALTER TABLE order_items
+    DROP COLUMN IF EXISTS variant_id;
+
+DROP INDEX IF EXISTS idx_stock_reservations_active;
+ALTER TABLE stock_reservations
+    DROP COLUMN IF EXISTS variant_id;
+CREATE UNIQUE INDEX idx_stock_reservations_active
+    ON stock_reservations(product_id, owner_type, owner_id)
+    WHERE status = 'active';
+
+-- Рядки кошика з варіантами не вмістяться в старий унікальний ключ
+DELETE FROM cart_items WHERE variant_id IS NOT NULL;
+DROP INDEX IF EXISTS idx_cart_items_user_product_variant;
+ALTER TABLE cart_items
+    DROP COLUMN IF EXISTS variant_id;
+ALTER TABLE cart_items
+    ADD CONSTRAINT cart_items_user_id_product_id_key UNIQUE (user_id, product_id);
+
+DROP TRIGGER IF EXISTS trg_product_variants_sync_stock ON product_variants;
+DROP FUNCTION IF EXISTS product_variants_sync_stock();
+
+DROP INDEX IF EXISTS idx_product_variants_product;
+DROP TABLE IF EXISTS product_variants;
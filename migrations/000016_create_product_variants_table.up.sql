@@ -0,0 +1,57 @@
+-- Варіанти товару (розмір, колір). Товар без варіантів продається як є,
+-- з залишком у products.stock
+CREATE TABLE IF NOT EXISTS product_variants (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    sku VARCHAR(64) UNIQUE NOT NULL,
+    options JSONB NOT NULL DEFAULT '{}',
+    price DECIMAL(10, 2) CHECK (price > 0),
+    stock INTEGER NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (product_id, options)
+);
+
+CREATE INDEX idx_product_variants_product ON product_variants(product_id);
+
+-- products.stock of a product with variants is the total of its variants;
+-- any variant change bumps the product version, so ETags cover the matrix
+CREATE OR REPLACE FUNCTION product_variants_sync_stock() RETURNS TRIGGER AS $$
+DECLARE
+    pid UUID;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        pid := OLD.product_id;
+    ELSE
+        pid := NEW.product_id;
+    END IF;
+
+    UPDATE products
+    SET stock = (SELECT COALESCE(SUM(stock), 0) FROM product_variants WHERE product_id = pid),
+        version = version + 1,
+        updated_at = NOW()
+    WHERE id = pid;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_product_variants_sync_stock
+    AFTER INSERT OR UPDATE OR DELETE ON product_variants
+    FOR EACH ROW EXECUTE FUNCTION product_variants_sync_stock();
+
+-- Рядки кошика, резерви та позиції замовлень вказують на варіант, якщо він є
+ALTER TABLE cart_items
+    ADD COLUMN IF NOT EXISTS variant_id UUID REFERENCES product_variants(id) ON DELETE CASCADE,
+    DROP CONSTRAINT IF EXISTS cart_items_user_id_product_id_key;
+CREATE UNIQUE INDEX idx_cart_items_user_product_variant
+    ON cart_items(user_id, product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'));
+
+ALTER TABLE stock_reservations
+    ADD COLUMN IF NOT EXISTS variant_id UUID REFERENCES product_variants(id) ON DELETE CASCADE;
+DROP INDEX IF EXISTS idx_stock_reservations_active;
+CREATE UNIQUE INDEX idx_stock_reservations_active
+    ON stock_reservations(product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'), owner_type, owner_id)
+    WHERE status = 'active';
+
+ALTER TABLE order_items
+    ADD COLUMN IF NOT EXISTS variant_id UUID REFERENCES product_variants(id);
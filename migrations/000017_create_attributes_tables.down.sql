@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_product_attribute_values_bool;
+DROP INDEX IF EXISTS idx_product_attribute_values_number;
+DROP INDEX IF EXISTS idx_product_attribute_values_text;
+DROP TABLE IF EXISTS product_attribute_values;
+DROP TABLE IF EXISTS attributes;
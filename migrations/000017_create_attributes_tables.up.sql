@@ -0,0 +1,25 @@
+-- Типізовані атрибути товарів (бренд, колір, обʼєм) для фільтрів і фасетів
+CREATE TABLE IF NOT EXISTS attributes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(50) UNIQUE NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('text', 'number', 'boolean')),
+    unit VARCHAR(20),
+    filterable BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Exactly one value column is set, the one of the attribute's type
+CREATE TABLE IF NOT EXISTS product_attribute_values (
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    attribute_id UUID NOT NULL REFERENCES attributes(id) ON DELETE CASCADE,
+    value_text VARCHAR(255),
+    value_number NUMERIC,
+    value_bool BOOLEAN,
+    PRIMARY KEY (product_id, attribute_id),
+    CHECK (num_nonnulls(value_text, value_number, value_bool) = 1)
+);
+
+CREATE INDEX idx_product_attribute_values_text ON product_attribute_values(attribute_id, lower(value_text));
+CREATE INDEX idx_product_attribute_values_number ON product_attribute_values(attribute_id, value_number);
+CREATE INDEX idx_product_attribute_values_bool ON product_attribute_values(attribute_id, value_bool);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_product_images_product_position;
+DROP TABLE IF EXISTS product_images;
@@ -0,0 +1,18 @@
+-- Галерея зображень товару; перше за position стає products.image_url
+CREATE TABLE IF NOT EXISTS product_images (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    storage_key VARCHAR(255) NOT NULL UNIQUE,
+    url VARCHAR(500) NOT NULL,
+    content_type VARCHAR(50) NOT NULL,
+    size_bytes BIGINT NOT NULL CHECK (size_bytes > 0),
+    width INTEGER NOT NULL CHECK (width > 0),
+    height INTEGER NOT NULL CHECK (height > 0),
+    alt_text VARCHAR(255),
+    position INTEGER NOT NULL CHECK (position >= 0),
+    -- [{"width": 160, "height": 120, "url": "..."}], narrowest first
+    thumbnails JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_product_images_product_position ON product_images(product_id, position);
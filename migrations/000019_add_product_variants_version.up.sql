@@ -0,0 +1,3 @@
+-- Оптимістичне блокування варіантів, як у products: кожна зміна,
+-- зокрема залишку через резерви, збільшує version
+ALTER TABLE product_variants ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
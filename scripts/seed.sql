@@ -1,15 +1,15 @@
 -- Очистити таблиці
-TRUNCATE TABLE order_items, orders, cart_items, products, categories, users CASCADE;
+TRUNCATE TABLE refresh_tokens, stock_reservations, order_status_history, order_items, orders, cart_items, product_variants, product_images, product_attribute_values, attributes, products, categories, users CASCADE;
 
 -- ============================================
 -- Користувачі (пароль для всіх: Test123!)
 -- ============================================
 INSERT INTO users (id, email, password_hash, first_name, last_name, role) VALUES
 ('11111111-1111-1111-1111-111111111111', 'admin@test.com',
- '$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy',
+ '$2a$10$Qxqa4VH29DuVDWh1rBZfzuFy7eiDOSvXhFkriu9lZOcIhtP/f5M.u',
  'Admin', 'User', 'admin'),
 ('22222222-2222-2222-2222-222222222222', 'user@test.com',
- '$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy',
+ '$2a$10$Qxqa4VH29DuVDWh1rBZfzuFy7eiDOSvXhFkriu9lZOcIhtP/f5M.u',
  'John', 'Doe', 'customer');
 
 -- ============================================
@@ -20,6 +20,11 @@ INSERT INTO categories (id, name, description) VALUES
 ('c2222222-2222-2222-2222-222222222222', 'Books', 'Books and e-books'),
 ('c3333333-3333-3333-3333-333333333333', 'Clothing', 'Fashion and apparel');
 
+-- Підкатегорії: Electronics > Audio > Headphones
+INSERT INTO categories (id, name, description, parent_id) VALUES
+('c4444444-4444-4444-4444-444444444444', 'Audio', 'Audio equipment', 'c1111111-1111-1111-1111-111111111111'),
+('c5555555-5555-5555-5555-555555555555', 'Headphones', 'Headphones and earbuds', 'c4444444-4444-4444-4444-444444444444');
+
 -- ============================================
 -- Товари (мінімум 20)
 -- ============================================
@@ -30,7 +35,7 @@ INSERT INTO products (name, description, price, stock, category_id) VALUES
 ('USB-C Hub', '7-in-1 hub', 49.99, 150, 'c1111111-1111-1111-1111-111111111111'),
 ('Keyboard', 'Mechanical keyboard', 89.99, 100, 'c1111111-1111-1111-1111-111111111111'),
 ('Webcam', '1080p webcam', 59.99, 80, 'c1111111-1111-1111-1111-111111111111'),
-('Headphones', 'Wireless headphones', 149.99, 120, 'c1111111-1111-1111-1111-111111111111'),
+('Headphones', 'Wireless headphones', 149.99, 120, 'c5555555-5555-5555-5555-555555555555'),
 ('SSD 1TB', 'Portable SSD', 119.99, 90, 'c1111111-1111-1111-1111-111111111111'),
 ('Monitor 27"', '4K monitor', 399.99, 60, 'c1111111-1111-1111-1111-111111111111'),
 
@@ -50,4 +55,60 @@ INSERT INTO products (name, description, price, stock, category_id) VALUES
 ('Cap', 'Baseball cap', 14.99, 400, 'c3333333-3333-3333-3333-333333333333'),
 ('Socks Pack', '5 pairs', 9.99, 600, 'c3333333-3333-3333-3333-333333333333');
 
+-- ============================================
+-- Варіанти одягу (залишок товару = сума варіантів)
+-- ============================================
+INSERT INTO product_variants (product_id, sku, options, price, stock)
+SELECT p.id, 'TSHIRT-' || c.code || '-' || s.size,
+       jsonb_build_object('size', s.size, 'color', c.color), s.price, 60
+FROM products p,
+     (VALUES ('BLK', 'black'), ('WHT', 'white')) AS c(code, color),
+     (VALUES ('S', NULL::DECIMAL), ('M', NULL), ('L', NULL), ('XL', 21.99)) AS s(size, price)
+WHERE p.name = 'T-Shirt';
+
+INSERT INTO product_variants (product_id, sku, options, stock)
+SELECT p.id, 'JEANS-' || c.code || '-' || s.size,
+       jsonb_build_object('size', s.size, 'color', c.color), 35
+FROM products p,
+     (VALUES ('BLU', 'blue'), ('BLK', 'black')) AS c(code, color),
+     (VALUES ('30'), ('32'), ('34'), ('36')) AS s(size)
+WHERE p.name = 'Jeans';
+
+INSERT INTO product_variants (product_id, sku, options, stock)
+SELECT p.id, 'SNKR-WHT-' || s.size,
+       jsonb_build_object('size', s.size, 'color', 'white'), 30
+FROM products p,
+     (VALUES ('40'), ('41'), ('42'), ('43'), ('44')) AS s(size)
+WHERE p.name = 'Sneakers';
+
+-- ============================================
+-- Атрибути для фільтрів
+-- ============================================
+INSERT INTO attributes (id, code, name, type, unit) VALUES
+('a1111111-1111-1111-1111-111111111111', 'brand', 'Brand', 'text', NULL),
+('a2222222-2222-2222-2222-222222222222', 'wireless', 'Wireless', 'boolean', NULL),
+('a3333333-3333-3333-3333-333333333333', 'capacity', 'Capacity', 'number', 'GB'),
+('a4444444-4444-4444-4444-444444444444', 'material', 'Material', 'text', NULL);
+
+INSERT INTO product_attribute_values (product_id, attribute_id, value_text, value_number, value_bool)
+SELECT p.id, v.attribute_id::uuid, v.value_text, v.value_number, v.value_bool
+FROM products p
+JOIN (VALUES
+    ('Laptop Pro', 'a1111111-1111-1111-1111-111111111111', 'Lenovo', NULL::NUMERIC, NULL::BOOLEAN),
+    ('Laptop Pro', 'a3333333-3333-3333-3333-333333333333', NULL, 512, NULL),
+    ('Wireless Mouse', 'a1111111-1111-1111-1111-111111111111', 'Logitech', NULL, NULL),
+    ('Wireless Mouse', 'a2222222-2222-2222-2222-222222222222', NULL, NULL, TRUE),
+    ('Keyboard', 'a1111111-1111-1111-1111-111111111111', 'Logitech', NULL, NULL),
+    ('Keyboard', 'a2222222-2222-2222-2222-222222222222', NULL, NULL, FALSE),
+    ('Webcam', 'a1111111-1111-1111-1111-111111111111', 'Logitech', NULL, NULL),
+    ('Headphones', 'a1111111-1111-1111-1111-111111111111', 'Sony', NULL, NULL),
+    ('Headphones', 'a2222222-2222-2222-2222-222222222222', NULL, NULL, TRUE),
+    ('SSD 1TB', 'a1111111-1111-1111-1111-111111111111', 'Samsung', NULL, NULL),
+    ('SSD 1TB', 'a3333333-3333-3333-3333-333333333333', NULL, 1024, NULL),
+    ('Monitor 27"', 'a1111111-1111-1111-1111-111111111111', 'Samsung', NULL, NULL),
+    ('T-Shirt', 'a4444444-4444-4444-4444-444444444444', 'Cotton', NULL, NULL),
+    ('Jeans', 'a4444444-4444-4444-4444-444444444444', 'Denim', NULL, NULL),
+    ('Hoodie', 'a4444444-4444-4444-4444-444444444444', 'Cotton', NULL, NULL)
+) AS v(product_name, attribute_id, value_text, value_number, value_bool) ON v.product_name = p.name;
+
 SELECT 'Seed data loaded!' AS status;